@@ -0,0 +1,108 @@
+package gee
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultDecompressedBytes = 10 << 20 // 10 MB
+
+// MaxBodyBytes caps the request body of the routes it is used on, e.g. a
+// group that accepts uploads. It can only tighten Engine.MaxBodyBytes,
+// the smaller of the two wins.
+func MaxBodyBytes(n int64) Handler {
+	return func(c *Context) {
+		if c.r.ContentLength > n {
+			c.Fail(http.StatusRequestEntityTooLarge, "request body too large")
+			c.Abort()
+			return
+		}
+
+		c.r.Body = http.MaxBytesReader(c.w, c.r.Body, n)
+		c.Next()
+	}
+}
+
+// BodyBytes reads the whole request body. It fails with ErrBodyTooLarge
+// when the body is over a MaxBodyBytes or Decompress limit.
+func (c *Context) BodyBytes() ([]byte, error) {
+	body, err := io.ReadAll(c.r.Body)
+	if err != nil {
+		return nil, c.bodyError(err)
+	}
+	return body, nil
+}
+
+// Decompress transparently inflates gzip and deflate request bodies.
+// Reading more than maxBytes of inflated data fails like an exceeded
+// MaxBodyBytes, which stops decompression bombs; 0 means 10 MB. Other
+// encodings are answered with 415.
+func Decompress(maxBytes int64) Handler {
+	if maxBytes <= 0 {
+		maxBytes = defaultDecompressedBytes
+	}
+
+	return func(c *Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.r.Header.Get("Content-Encoding")))
+		if encoding == "" || encoding == "identity" {
+			c.Next()
+			return
+		}
+
+		var body io.ReadCloser
+		var err error
+		switch encoding {
+		case "gzip", "x-gzip":
+			body, err = gzip.NewReader(c.r.Body)
+		case "deflate":
+			body, err = newDeflateReader(c.r.Body)
+		default:
+			c.Fail(http.StatusUnsupportedMediaType, "unsupported content encoding")
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.Fail(http.StatusBadRequest, "malformed "+encoding+" body")
+			c.Abort()
+			return
+		}
+
+		c.r.Body = http.MaxBytesReader(c.w, &decompressedBody{ReadCloser: body, raw: c.r.Body}, maxBytes)
+		c.r.Header.Del("Content-Encoding")
+		c.r.Header.Del("Content-Length")
+		c.r.ContentLength = -1
+
+		c.Next()
+	}
+}
+
+// newDeflateReader accepts the zlib wrapped stream the spec asks for as
+// well as the raw deflate some clients send instead.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressedBody closes both the decompressor and the wire body.
+type decompressedBody struct {
+	io.ReadCloser
+	raw io.Closer
+}
+
+func (b *decompressedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.raw.Close()
+}
@@ -0,0 +1,131 @@
+package gee
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		w, _ = flate.NewWriter(&buf, flate.BestCompression)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func echoBody(c *Context) {
+	body, err := c.BodyBytes()
+	if errors.Is(err, ErrBodyTooLarge) {
+		c.String(http.StatusRequestEntityTooLarge, "%v", err)
+		return
+	}
+	if err != nil {
+		c.String(http.StatusBadRequest, "%v", err)
+		return
+	}
+	c.Data(http.StatusOK, body)
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	e := New()
+	e.MaxBodyBytes = 1024
+	upload := e.Group("/upload")
+	upload.Use(MaxBodyBytes(16))
+	upload.POST("/small", echoBody)
+	e.POST("/api", echoBody)
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		chunked        bool
+		expectedStatus int
+	}{
+		{name: "within group limit", path: "/upload/small", body: "hello", expectedStatus: http.StatusOK},
+		{name: "content length over group limit", path: "/upload/small", body: strings.Repeat("x", 32), expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "chunked over group limit", path: "/upload/small", body: strings.Repeat("x", 32), chunked: true, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "other routes keep the engine limit", path: "/api", body: strings.Repeat("x", 32), expectedStatus: http.StatusOK},
+		{name: "engine limit", path: "/api", body: strings.Repeat("x", 2048), chunked: true, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	payload := []byte(`{"message":"` + strings.Repeat("gee ", 100) + `"}`)
+	bomb := bytes.Repeat([]byte{0}, 1<<20)
+
+	tests := []struct {
+		name           string
+		encoding       string
+		body           []byte
+		expectedStatus int
+		expectedBody   []byte
+	}{
+		{name: "plain", body: payload, expectedStatus: http.StatusOK, expectedBody: payload},
+		{name: "gzip", encoding: "gzip", body: compress(t, "gzip", payload), expectedStatus: http.StatusOK, expectedBody: payload},
+		{name: "zlib deflate", encoding: "deflate", body: compress(t, "zlib", payload), expectedStatus: http.StatusOK, expectedBody: payload},
+		{name: "raw deflate", encoding: "deflate", body: compress(t, "flate", payload), expectedStatus: http.StatusOK, expectedBody: payload},
+		{name: "bomb", encoding: "gzip", body: compress(t, "gzip", bomb), expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "malformed", encoding: "gzip", body: []byte("not gzip"), expectedStatus: http.StatusBadRequest},
+		{name: "unsupported", encoding: "br", body: payload, expectedStatus: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.Use(Decompress(4096))
+			e.POST("/echo", func(c *Context) {
+				if c.GetHeader("Content-Encoding") != "" {
+					t.Errorf("Expected Content-Encoding to be removed")
+				}
+				echoBody(c)
+			})
+
+			req := httptest.NewRequest("POST", "/echo", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedBody != nil && !bytes.Equal(rr.Body.Bytes(), tt.expectedBody) {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package gee
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SetTrustedProxies sets the proxies whose forwarding headers ClientIP will
+// believe. Each entry is either a CIDR ("10.0.0.0/8") or a single IP.
+// Passing nil trusts no proxy, which is the default.
+func (e *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("gee: invalid trusted proxy %q", proxy)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			cidrs = append(cidrs, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("gee: invalid trusted proxy %q: %w", proxy, err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+
+	e.trustedCIDRs = cidrs
+	return nil
+}
+
+func (e *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, cidr := range e.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RemoteIP returns the IP of the peer that opened the connection, ignoring
+// any forwarding headers.
+func (c *Context) RemoteIP() string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.r.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(c.r.RemoteAddr)
+	}
+
+	return host
+}
+
+// fromTrustedProxy reports whether the direct peer is a trusted proxy, the
+// only case in which forwarding headers may be believed.
+func (c *Context) fromTrustedProxy() bool {
+	ip := net.ParseIP(c.RemoteIP())
+	return ip != nil && c.engine != nil && c.engine.isTrustedProxy(ip)
+}
+
+// ClientIP returns the originating client IP. X-Forwarded-For, X-Real-IP and
+// Forwarded are only consulted when the direct peer is a trusted proxy.
+func (c *Context) ClientIP() string {
+	remoteIP := c.RemoteIP()
+	if !c.fromTrustedProxy() {
+		return remoteIP
+	}
+
+	if values := c.r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		if client, ok := c.engine.lastUntrusted(strings.Split(strings.Join(values, ","), ",")); ok {
+			return client
+		}
+	}
+
+	if value := strings.TrimSpace(c.r.Header.Get("X-Real-IP")); net.ParseIP(value) != nil {
+		return value
+	}
+
+	if values := c.r.Header.Values("Forwarded"); len(values) > 0 {
+		if client, ok := c.engine.lastUntrusted(parseForwardedFor(values)); ok {
+			return client
+		}
+	}
+
+	return remoteIP
+}
+
+// lastUntrusted walks a proxy chain from right to left and returns the first
+// hop that is not a trusted proxy. If every hop is trusted the leftmost one
+// is returned.
+func (e *Engine) lastUntrusted(chain []string) (string, bool) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		value := strings.TrimSpace(chain[i])
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", false
+		}
+		if i == 0 || !e.isTrustedProxy(ip) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// parseForwardedFor extracts the "for" parameters of an RFC 7239 Forwarded
+// header, stripping quotes, IPv6 brackets and ports.
+func parseForwardedFor(values []string) []string {
+	chain := make([]string, 0)
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(k, "for") {
+					continue
+				}
+
+				v = strings.Trim(v, `"`)
+				if host, _, err := net.SplitHostPort(v); err == nil {
+					v = host
+				}
+				chain = append(chain, strings.Trim(v, "[]"))
+			}
+		}
+	}
+
+	return chain
+}
@@ -0,0 +1,131 @@
+package gee
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEngine_SetTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name        string
+		proxies     []string
+		expectedErr bool
+	}{
+		{
+			name:    "cidr and single ip",
+			proxies: []string{"10.0.0.0/8", "192.168.1.1", "::1"},
+		},
+		{
+			name:        "invalid ip",
+			proxies:     []string{"not-an-ip"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid cidr",
+			proxies:     []string{"10.0.0.0/99"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			err := e.SetTrustedProxies(tt.proxies)
+
+			if (err != nil) != tt.expectedErr {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestContext_ClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		headers    map[string]string
+		expectedIP string
+	}{
+		{
+			name:       "no proxy configured ignores headers",
+			remoteAddr: "203.0.113.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.1.1.1"},
+			expectedIP: "203.0.113.1",
+		},
+		{
+			name:       "untrusted peer ignores headers",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "203.0.113.1:1234",
+			headers:    map[string]string{"X-Real-IP": "1.1.1.1"},
+			expectedIP: "203.0.113.1",
+		},
+		{
+			name:       "x-forwarded-for skips trusted hops",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "6.6.6.6, 1.1.1.1, 10.0.0.2"},
+			expectedIP: "1.1.1.1",
+		},
+		{
+			name:       "x-forwarded-for all trusted returns leftmost",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			expectedIP: "10.0.0.3",
+		},
+		{
+			name:       "x-real-ip",
+			trusted:    []string{"10.0.0.1"},
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Real-IP": "1.1.1.1"},
+			expectedIP: "1.1.1.1",
+		},
+		{
+			name:       "forwarded with ipv6 and port",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https, for=10.0.0.5`},
+			expectedIP: "2001:db8::1",
+		},
+		{
+			name:       "invalid header falls back to remote",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "garbage"},
+			expectedIP: "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			if err := e.SetTrustedProxies(tt.trusted); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			c := newContext(httptest.NewRecorder(), req)
+			c.engine = e
+
+			if ip := c.ClientIP(); ip != tt.expectedIP {
+				t.Errorf("Expected client ip %q, got %q", tt.expectedIP, ip)
+			}
+		})
+	}
+}
+
+func TestContext_RemoteIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "[::1]:8080"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+	c := newContext(httptest.NewRecorder(), req)
+
+	if ip := c.RemoteIP(); ip != "::1" {
+		t.Errorf("Expected remote ip %q, got %q", "::1", ip)
+	}
+}
@@ -3,7 +3,9 @@ package gee
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type H map[string]any
@@ -13,10 +15,13 @@ type Context struct {
 	r          *http.Request
 	method     string
 	path       string
+	fullPath   string
 	statusCode int
 	params     map[string]string
 	handlers   HandlerChain
 	index      int
+	engine     *Engine
+	keys       map[string]any
 }
 
 func newContext(w http.ResponseWriter, r *http.Request) *Context {
@@ -31,10 +36,91 @@ func newContext(w http.ResponseWriter, r *http.Request) *Context {
 	}
 }
 
+// NewContext builds a Context bound to e outside of ServeHTTP, running
+// handlers on Next. It is meant for tests and adapters.
+func (e *Engine) NewContext(w http.ResponseWriter, r *http.Request, handlers ...Handler) *Context {
+	c := newContext(w, r)
+	c.engine = e
+	c.handlers = handlers
+	return c
+}
+
 func (c *Context) Param(key string) string {
 	return c.params[key]
 }
 
+func (c *Context) ParamInt(key string) (int, error) {
+	v, err := c.ParamInt64(key)
+	if err == nil && int64(int(v)) != v {
+		return 0, fmt.Errorf("gee: param %q: %d overflows int", key, v)
+	}
+	return int(v), err
+}
+
+func (c *Context) ParamInt64(key string) (int64, error) {
+	v, err := strconv.ParseInt(c.params[key], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("gee: param %q: %w", key, err)
+	}
+	return v, nil
+}
+
+func (c *Context) ParamUint64(key string) (uint64, error) {
+	v, err := strconv.ParseUint(c.params[key], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("gee: param %q: %w", key, err)
+	}
+	return v, nil
+}
+
+func (c *Context) ParamFloat64(key string) (float64, error) {
+	v, err := strconv.ParseFloat(c.params[key], 64)
+	if err != nil {
+		return 0, fmt.Errorf("gee: param %q: %w", key, err)
+	}
+	return v, nil
+}
+
+func (c *Context) AddParam(key, value string) {
+	c.params[key] = value
+}
+
+func (c *Context) Set(key string, value any) {
+	if c.keys == nil {
+		c.keys = make(map[string]any)
+	}
+	c.keys[key] = value
+}
+
+func (c *Context) Get(key string) (value any, ok bool) {
+	value, ok = c.keys[key]
+	return
+}
+
+func (c *Context) Request() *http.Request {
+	return c.r
+}
+
+func (c *Context) Writer() http.ResponseWriter {
+	return c.w
+}
+
+func (c *Context) GetHeader(key string) string {
+	return c.r.Header.Get(key)
+}
+
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	http.SetCookie(c.w, cookie)
+}
+
 func (c *Context) Method() string {
 	return c.method
 }
@@ -43,6 +129,20 @@ func (c *Context) Path() string {
 	return c.path
 }
 
+// FullPath returns the pattern of the matched route, e.g. /hello/:name, or
+// "" when no route matched.
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
+// StatusCode returns the status written so far, 0 if nothing was written.
+func (c *Context) StatusCode() int {
+	if rw, ok := c.w.(*responseWriter); ok {
+		return rw.status
+	}
+	return c.statusCode
+}
+
 func (c *Context) PostForm(key string) string {
 	return c.r.FormValue(key)
 }
@@ -88,6 +188,25 @@ func (c *Context) Next() {
 	}
 }
 
+// abortIndex is far beyond any chain, so Next stops even after the
+// increment that follows the aborting handler.
+const abortIndex = math.MaxInt / 2
+
+// Abort stops the remaining handlers of the chain from running. Handlers
+// that already called Next still finish their own code.
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Abort()
+}
+
 func (c *Context) Fail(code int, errMsg string) {
 	c.SetHeader("Content-Type", "test/plain")
 	c.Status(code)
@@ -834,3 +834,67 @@ func TestContext_Fail(t *testing.T) {
 		})
 	}
 }
+
+func TestContext_HeaderAndCookie(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Token", "abc")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+	rr := httptest.NewRecorder()
+
+	c := newContext(rr, req)
+
+	if c.Request() != req || c.Writer() != rr {
+		t.Error("Expected Request and Writer to return the underlying values")
+	}
+
+	if got := c.GetHeader("X-Token"); got != "abc" {
+		t.Errorf("Expected header %q, got %q", "abc", got)
+	}
+
+	if got, err := c.Cookie("session"); err != nil || got != "s1" {
+		t.Errorf("Expected cookie %q, got %q (%v)", "s1", got, err)
+	}
+
+	if _, err := c.Cookie("missing"); err == nil {
+		t.Error("Expected error for missing cookie")
+	}
+
+	c.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	if got := rr.Header().Get("Set-Cookie"); got != "theme=dark" {
+		t.Errorf("Expected Set-Cookie %q, got %q", "theme=dark", got)
+	}
+}
+
+func TestContext_Abort(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	rr := httptest.NewRecorder()
+
+	c := newContext(rr, req)
+	c.handlers = []Handler{
+		func(c *Context) {
+			c.Next()
+			c.w.Write([]byte("-after"))
+		},
+		func(c *Context) {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			c.w.Write([]byte("handler2"))
+		},
+		func(c *Context) {
+			c.w.Write([]byte("-handler3"))
+		},
+	}
+
+	c.Next()
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+
+	if rr.Body.String() != "handler2-after" {
+		t.Errorf("Expected output %q, got %q", "handler2-after", rr.Body.String())
+	}
+
+	if !c.IsAborted() {
+		t.Error("Expected context to be aborted")
+	}
+}
@@ -0,0 +1,326 @@
+// Package csrf protects cookie-authenticated routes against cross-site
+// request forgery with either the double-submit cookie or the synchronizer
+// token pattern.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/loveRyujin/gee"
+)
+
+const (
+	tokenKey    = "gee.csrf.token"
+	tokenLength = 32
+)
+
+var (
+	ErrTokenMissing = errors.New("csrf: token missing")
+	ErrTokenInvalid = errors.New("csrf: token invalid")
+	ErrBadOrigin    = errors.New("csrf: origin not allowed")
+	ErrBadReferer   = errors.New("csrf: referer not allowed")
+	ErrNoSession    = errors.New("csrf: no session")
+)
+
+type Mode int
+
+const (
+	// DoubleSubmit keeps the token in a cookie, a forged request can send
+	// the cookie but cannot read it to echo the token.
+	DoubleSubmit Mode = iota
+	// Synchronizer keeps the token server side in Store, keyed by session.
+	Synchronizer
+)
+
+type Config struct {
+	Mode Mode
+	// HeaderName and FieldName carry the token on unsafe requests,
+	// "X-CSRF-Token" and "csrf_token" by default.
+	HeaderName string
+	FieldName  string
+	// TrustedOrigins are extra hosts, such as "admin.example.com", allowed
+	// in Origin and Referer besides the request's own Host.
+	TrustedOrigins []string
+
+	// Cookie settings of DoubleSubmit, the cookie is named "_csrf" and
+	// lives for 12 hours by default.
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	CookieSecure bool
+	CookieMaxAge time.Duration
+
+	// Store and SessionKey are required by Synchronizer. SessionKey returns
+	// the id of the caller's session, "" when there is none.
+	Store      Store
+	SessionKey func(c *gee.Context) string
+
+	// Skip lets requests through unchecked when it returns true.
+	Skip func(c *gee.Context) bool
+	// ErrorHandler answers a rejected request, 403 with the error text by
+	// default. The chain is aborted either way.
+	ErrorHandler func(c *gee.Context, err error)
+}
+
+// Store holds synchronizer tokens per session.
+type Store interface {
+	Get(session string) (token []byte, ok bool)
+	Set(session string, token []byte)
+}
+
+type CSRF struct {
+	cfg     Config
+	trusted map[string]bool
+	exempt  sync.Map // "METHOD pattern" of opted-out routes
+}
+
+func New(cfg Config) *CSRF {
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = "X-CSRF-Token"
+	}
+	if cfg.FieldName == "" {
+		cfg.FieldName = "csrf_token"
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = "_csrf"
+	}
+	if cfg.CookiePath == "" {
+		cfg.CookiePath = "/"
+	}
+	if cfg.CookieMaxAge == 0 {
+		cfg.CookieMaxAge = 12 * time.Hour
+	}
+	if cfg.Mode == Synchronizer && (cfg.Store == nil || cfg.SessionKey == nil) {
+		panic("csrf: Synchronizer needs a Store and a SessionKey")
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(c *gee.Context, err error) {
+			c.Fail(http.StatusForbidden, err.Error())
+		}
+	}
+
+	p := &CSRF{cfg: cfg, trusted: make(map[string]bool, len(cfg.TrustedOrigins))}
+	for _, origin := range cfg.TrustedOrigins {
+		p.trusted[origin] = true
+	}
+
+	return p
+}
+
+// Exempt opts routes out of the check, e.g. a webhook signed some other
+// way:
+//
+//	p.Exempt(r.POST("/hooks/github", onPush))
+func (p *CSRF) Exempt(routes ...*gee.Route) {
+	for _, rt := range routes {
+		p.exempt.Store(rt.Method()+" "+rt.Pattern(), true)
+	}
+}
+
+// Middleware makes the token available through Token and rejects unsafe
+// requests whose Origin, Referer or token don't check out.
+func (p *CSRF) Middleware() gee.Handler {
+	return func(c *gee.Context) {
+		token, err := p.token(c)
+		if err == nil {
+			c.Set(tokenKey, token)
+		}
+
+		if safeMethod(c.Method()) || p.skipped(c) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			p.reject(c, err)
+			return
+		}
+
+		if err := p.checkOrigin(c.Request()); err != nil {
+			p.reject(c, err)
+			return
+		}
+
+		sent := c.GetHeader(p.cfg.HeaderName)
+		if sent == "" {
+			sent = c.PostForm(p.cfg.FieldName)
+		}
+		if sent == "" {
+			p.reject(c, ErrTokenMissing)
+			return
+		}
+		if got, ok := unmask(sent); !ok || subtle.ConstantTimeCompare(got, token) != 1 {
+			p.reject(c, ErrTokenInvalid)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Token returns the token to embed in forms or hand to scripts. It is
+// masked differently on every call so it can't be recovered by BREACH
+// style compression attacks.
+func Token(c *gee.Context) string {
+	v, _ := c.Get(tokenKey)
+	token, ok := v.([]byte)
+	if !ok {
+		return ""
+	}
+
+	return mask(token)
+}
+
+func (p *CSRF) reject(c *gee.Context, err error) {
+	p.cfg.ErrorHandler(c, err)
+	c.Abort()
+}
+
+func (p *CSRF) skipped(c *gee.Context) bool {
+	if p.cfg.Skip != nil && p.cfg.Skip(c) {
+		return true
+	}
+	_, ok := p.exempt.Load(c.Method() + " " + c.FullPath())
+	return ok
+}
+
+// token loads the caller's token, issuing a new one if there is none yet.
+func (p *CSRF) token(c *gee.Context) ([]byte, error) {
+	if p.cfg.Mode == Synchronizer {
+		session := p.cfg.SessionKey(c)
+		if session == "" {
+			return nil, ErrNoSession
+		}
+		if token, ok := p.cfg.Store.Get(session); ok && len(token) == tokenLength {
+			return token, nil
+		}
+		token := newToken()
+		p.cfg.Store.Set(session, token)
+		return token, nil
+	}
+
+	if value, err := c.Cookie(p.cfg.CookieName); err == nil {
+		if token, err := base64.RawURLEncoding.DecodeString(value); err == nil && len(token) == tokenLength {
+			return token, nil
+		}
+	}
+
+	token := newToken()
+	c.SetCookie(&http.Cookie{
+		Name:     p.cfg.CookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(token),
+		Path:     p.cfg.CookiePath,
+		Domain:   p.cfg.CookieDomain,
+		MaxAge:   int(p.cfg.CookieMaxAge.Seconds()),
+		Secure:   p.cfg.CookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token, nil
+}
+
+// checkOrigin requires Origin, or failing that Referer, to name this host
+// or a trusted one. A request without either is only accepted over plain
+// HTTP, where browsers may legitimately strip Referer.
+func (p *CSRF) checkOrigin(r *http.Request) error {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !p.allowed(r, origin) {
+			return ErrBadOrigin
+		}
+		return nil
+	}
+
+	referer := r.Header.Get("Referer")
+	if referer == "" {
+		if r.TLS != nil {
+			return ErrBadReferer
+		}
+		return nil
+	}
+	if !p.allowed(r, referer) {
+		return ErrBadReferer
+	}
+	return nil
+}
+
+func (p *CSRF) allowed(r *http.Request, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Host == r.Host || p.trusted[u.Host]
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func newToken() []byte {
+	token := make([]byte, tokenLength)
+	rand.Read(token)
+	return token
+}
+
+// mask returns pad followed by token XOR pad.
+func mask(token []byte) string {
+	out := make([]byte, 2*tokenLength)
+	pad, masked := out[:tokenLength], out[tokenLength:]
+	rand.Read(pad)
+	subtle.XORBytes(masked, token, pad)
+
+	return base64.RawURLEncoding.EncodeToString(out)
+}
+
+func unmask(s string) ([]byte, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != 2*tokenLength {
+		return nil, false
+	}
+
+	token := make([]byte, tokenLength)
+	subtle.XORBytes(token, b[tokenLength:], b[:tokenLength])
+	return token, true
+}
+
+// MemoryStore is a Store for a single process. Tokens are dropped together
+// with the session through Delete.
+type MemoryStore struct {
+	mu     sync.Mutex
+	tokens map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Get(session string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[session]
+	return token, ok
+}
+
+func (s *MemoryStore) Set(session string, token []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[session] = token
+}
+
+func (s *MemoryStore) Delete(session string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, session)
+}
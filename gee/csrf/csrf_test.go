@@ -0,0 +1,248 @@
+package csrf
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/gee"
+)
+
+func newEngine(p *CSRF) *gee.Engine {
+	e := gee.New()
+	e.Use(p.Middleware())
+	e.GET("/form", func(c *gee.Context) {
+		c.String(http.StatusOK, "%s", Token(c))
+	})
+	e.POST("/submit", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	p.Exempt(e.POST("/hooks", func(c *gee.Context) {
+		c.String(http.StatusOK, "hook")
+	}))
+	return e
+}
+
+// fetchToken does the GET a browser would and returns the cookie and the
+// token embedded in the page.
+func fetchToken(t *testing.T, e *gee.Engine) (*http.Cookie, string) {
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, httptest.NewRequest("GET", "/form", nil))
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "_csrf" || !cookies[0].HttpOnly {
+		t.Fatalf("Expected an HttpOnly _csrf cookie, got %v", cookies)
+	}
+	if rr.Body.Len() == 0 {
+		t.Fatal("Expected a token in the page")
+	}
+	return cookies[0], rr.Body.String()
+}
+
+func TestCSRF_DoubleSubmit(t *testing.T) {
+	p := New(Config{TrustedOrigins: []string{"admin.example.com"}})
+	e := newEngine(p)
+	cookie, token := fetchToken(t, e)
+	_, otherToken := fetchToken(t, e)
+
+	tests := []struct {
+		name           string
+		path           string
+		setup          func(r *http.Request)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "token in header",
+			setup:          func(r *http.Request) { r.Header.Set("X-CSRF-Token", token) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "token in form field",
+			setup: func(r *http.Request) {
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				r.Body = io.NopCloser(strings.NewReader(url.Values{"csrf_token": {token}}.Encode()))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing token",
+			setup:          func(r *http.Request) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   ErrTokenMissing.Error(),
+		},
+		{
+			name:           "token of another cookie",
+			setup:          func(r *http.Request) { r.Header.Set("X-CSRF-Token", otherToken) },
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   ErrTokenInvalid.Error(),
+		},
+		{
+			name:           "garbage token",
+			setup:          func(r *http.Request) { r.Header.Set("X-CSRF-Token", "not-a-token") },
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   ErrTokenInvalid.Error(),
+		},
+		{
+			name: "cross-site origin",
+			setup: func(r *http.Request) {
+				r.Header.Set("X-CSRF-Token", token)
+				r.Header.Set("Origin", "https://evil.example")
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   ErrBadOrigin.Error(),
+		},
+		{
+			name: "trusted origin",
+			setup: func(r *http.Request) {
+				r.Header.Set("X-CSRF-Token", token)
+				r.Header.Set("Origin", "https://admin.example.com")
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "cross-site referer",
+			setup: func(r *http.Request) {
+				r.Header.Set("X-CSRF-Token", token)
+				r.Header.Set("Referer", "https://evil.example/page")
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   ErrBadReferer.Error(),
+		},
+		{
+			name: "same-site referer",
+			setup: func(r *http.Request) {
+				r.Header.Set("X-CSRF-Token", token)
+				r.Header.Set("Referer", "http://example.com/form")
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "https without referer",
+			setup: func(r *http.Request) {
+				r.Header.Set("X-CSRF-Token", token)
+				r.TLS = &tls.ConnectionState{}
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   ErrBadReferer.Error(),
+		},
+		{
+			name:           "exempt route",
+			path:           "/hooks",
+			setup:          func(r *http.Request) {},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "hook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			if path == "" {
+				path = "/submit"
+			}
+			req := httptest.NewRequest("POST", path, nil)
+			req.AddCookie(cookie)
+			tt.setup(req)
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d (%s)", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedBody != "" && rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCSRF_TokenIsMaskedPerRequest(t *testing.T) {
+	e := newEngine(New(Config{}))
+	cookie, first := fetchToken(t, e)
+
+	req := httptest.NewRequest("GET", "/form", nil)
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+
+	if len(rr.Result().Cookies()) != 0 {
+		t.Errorf("Expected the existing cookie to be kept, got %v", rr.Result().Cookies())
+	}
+	second := rr.Body.String()
+	if first == second {
+		t.Errorf("Expected a differently masked token per request")
+	}
+
+	a, _ := unmask(first)
+	b, _ := unmask(second)
+	if string(a) != string(b) {
+		t.Errorf("Expected both tokens to unmask to the cookie token")
+	}
+}
+
+func TestCSRF_Synchronizer(t *testing.T) {
+	store := NewMemoryStore()
+	p := New(Config{
+		Mode:  Synchronizer,
+		Store: store,
+		SessionKey: func(c *gee.Context) string {
+			session, _ := c.Cookie("session")
+			return session
+		},
+	})
+	e := newEngine(p)
+
+	page := func(session string) string {
+		req := httptest.NewRequest("GET", "/form", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		if len(rr.Result().Cookies()) != 0 {
+			t.Errorf("Expected no csrf cookie in synchronizer mode")
+		}
+		return rr.Body.String()
+	}
+	alice, bob := page("alice"), page("bob")
+
+	tests := []struct {
+		name           string
+		session        string
+		token          string
+		expectedStatus int
+	}{
+		{name: "own token", session: "alice", token: alice, expectedStatus: http.StatusOK},
+		{name: "token of another session", session: "alice", token: bob, expectedStatus: http.StatusForbidden},
+		{name: "no session", session: "", token: alice, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/submit", nil)
+			if tt.session != "" {
+				req.AddCookie(&http.Cookie{Name: "session", Value: tt.session})
+			}
+			req.Header.Set("X-CSRF-Token", tt.token)
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+
+	store.Delete("alice")
+	req := httptest.NewRequest("POST", "/submit", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "alice"})
+	req.Header.Set("X-CSRF-Token", alice)
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected the token to die with the session, got %d", rr.Code)
+	}
+}
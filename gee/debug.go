@@ -0,0 +1,45 @@
+package gee
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+)
+
+// RegisterDebug adds profiling and runtime routes to g, so they sit behind
+// whatever auth middleware g uses:
+//
+//	debug := r.Group("/debug")
+//	debug.Use(requireAdmin())
+//	gee.RegisterDebug(debug)
+//
+// serves /debug/pprof/ (go tool pprof works against it), /debug/vars for
+// expvar, /debug/routes and /debug/goroutines. Importing net/http/pprof and
+// expvar also registers them on http.DefaultServeMux, so don't serve that
+// mux publicly.
+func RegisterDebug(g *RouteGroup) {
+	hidden := RouteDoc{Hidden: true}
+	e := g.engine
+
+	g.GET("/pprof/", WrapF(pprof.Index)).Doc(hidden)
+	g.GET("/pprof/cmdline", WrapF(pprof.Cmdline)).Doc(hidden)
+	g.GET("/pprof/profile", WrapF(pprof.Profile)).Doc(hidden)
+	g.GET("/pprof/symbol", WrapF(pprof.Symbol)).Doc(hidden)
+	g.POST("/pprof/symbol", WrapF(pprof.Symbol)).Doc(hidden)
+	g.GET("/pprof/trace", WrapF(pprof.Trace)).Doc(hidden)
+	// heap, goroutine, allocs, block, mutex, threadcreate
+	g.GET("/pprof/:name", func(c *Context) {
+		pprof.Handler(c.Param("name")).ServeHTTP(c.w, c.r)
+	}).Doc(hidden)
+
+	g.GET("/vars", WrapH(expvar.Handler())).Doc(hidden)
+	g.GET("/routes", func(c *Context) {
+		c.JSON(http.StatusOK, e.Routes())
+	}).Doc(hidden)
+	g.GET("/goroutines", func(c *Context) {
+		c.SetHeader("Content-Type", "text/plain; charset=utf-8")
+		c.Status(http.StatusOK)
+		runtimepprof.Lookup("goroutine").WriteTo(c.w, 2)
+	}).Doc(hidden)
+}
@@ -0,0 +1,64 @@
+package gee
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterDebug(t *testing.T) {
+	e := New()
+	debug := e.Group("/debug")
+	debug.Use(func(c *Context) {
+		if c.GetHeader("Authorization") != "Bearer admin" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	})
+	RegisterDebug(debug)
+	e.GET("/users/:id", func(c *Context) {})
+
+	tests := []struct {
+		path             string
+		anonymous        bool
+		expectedStatus   int
+		expectedContains string
+	}{
+		{path: "/debug/pprof/", expectedStatus: http.StatusOK, expectedContains: "goroutine?debug=1"},
+		{path: "/debug/pprof/heap?debug=1", expectedStatus: http.StatusOK, expectedContains: "heap profile"},
+		{path: "/debug/pprof/goroutine?debug=1", expectedStatus: http.StatusOK, expectedContains: "goroutine profile"},
+		{path: "/debug/pprof/cmdline", expectedStatus: http.StatusOK},
+		{path: "/debug/pprof/nosuch", expectedStatus: http.StatusNotFound},
+		{path: "/debug/vars", expectedStatus: http.StatusOK, expectedContains: `"memstats"`},
+		{path: "/debug/routes", expectedStatus: http.StatusOK, expectedContains: `"/users/:id"`},
+		{path: "/debug/goroutines", expectedStatus: http.StatusOK, expectedContains: "goroutine "},
+		{path: "/debug/vars", anonymous: true, expectedStatus: http.StatusUnauthorized},
+		{path: "/debug/pprof/heap", anonymous: true, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if !tt.anonymous {
+				req.Header.Set("Authorization", "Bearer admin")
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedContains) {
+				t.Errorf("Expected body to contain %q, got %.200q", tt.expectedContains, rr.Body.String())
+			}
+		})
+	}
+
+	doc, _ := json.Marshal(e.OpenAPI(OpenAPIInfo{Title: "t", Version: "1"}))
+	if strings.Contains(string(doc), "/debug/") {
+		t.Errorf("Expected debug routes to stay out of the OpenAPI document")
+	}
+}
@@ -0,0 +1,86 @@
+package gee
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag buffers GET and HEAD responses, tags successful ones with an ETag
+// (weak if asked) unless the handler set one, and answers If-None-Match and
+// If-Modified-Since with 304 Not Modified.
+func ETag(weak bool) Handler {
+	return func(c *Context) {
+		if c.method != http.MethodGet && c.method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		buf := c.bufferResponse()
+		header := c.w.Header()
+		if buf.Status() != http.StatusOK {
+			buf.flushTo(c.w)
+			return
+		}
+
+		etag := header.Get("ETag")
+		if etag == "" {
+			sum := sha256.Sum256(buf.body.Bytes())
+			etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+			if weak {
+				etag = "W/" + etag
+			}
+			header.Set("ETag", etag)
+		}
+
+		if notModified(c.r, etag, header.Get("Last-Modified")) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			c.w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		buf.flushTo(c.w)
+	}
+}
+
+// notModified follows RFC 9110 13.2.2: If-None-Match wins over
+// If-Modified-Since and is compared weakly.
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// CacheControl sets the Cache-Control header, e.g.
+//
+//	c.CacheControl("public", "max-age=60")
+func (c *Context) CacheControl(directives ...string) {
+	c.SetHeader("Cache-Control", strings.Join(directives, ", "))
+}
+
+func (c *Context) LastModified(t time.Time) {
+	c.SetHeader("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
@@ -0,0 +1,171 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	newEngine := func(weak bool) *Engine {
+		e := New()
+		e.Use(ETag(weak))
+		e.GET("/data", func(c *Context) {
+			c.CacheControl("public", "max-age=60")
+			c.LastModified(modified)
+			c.JSON(http.StatusOK, H{"msg": "hi"})
+		})
+		e.GET("/tagged", func(c *Context) {
+			c.SetHeader("ETag", `"v1"`)
+			c.String(http.StatusOK, "tagged")
+		})
+		e.GET("/missing", func(c *Context) {
+			c.String(http.StatusNotFound, "missing")
+		})
+		e.POST("/data", func(c *Context) {
+			c.String(http.StatusOK, "posted")
+		})
+		return e
+	}
+
+	strong := httptest.NewRecorder()
+	newEngine(false).ServeHTTP(strong, httptest.NewRequest("GET", "/data", nil))
+	etag := strong.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) || len(etag) != 34 {
+		t.Fatalf("Expected strong etag, got %q", etag)
+	}
+
+	tests := []struct {
+		name           string
+		weak           bool
+		method         string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   string
+		expectedETag   string
+	}{
+		{
+			name:           "adds etag and cache headers",
+			method:         "GET",
+			path:           "/data",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "{\"msg\":\"hi\"}\n",
+			expectedETag:   etag,
+		},
+		{
+			name:           "weak etag",
+			weak:           true,
+			method:         "GET",
+			path:           "/data",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "{\"msg\":\"hi\"}\n",
+			expectedETag:   "W/" + etag,
+		},
+		{
+			name:           "if-none-match hit",
+			method:         "GET",
+			path:           "/data",
+			headers:        map[string]string{"If-None-Match": `"other", ` + etag},
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   etag,
+		},
+		{
+			name:           "weak comparison",
+			method:         "GET",
+			path:           "/data",
+			headers:        map[string]string{"If-None-Match": "W/" + etag},
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   etag,
+		},
+		{
+			name:           "if-none-match miss ignores if-modified-since",
+			method:         "GET",
+			path:           "/data",
+			headers:        map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": modified.Format(http.TimeFormat)},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "{\"msg\":\"hi\"}\n",
+			expectedETag:   etag,
+		},
+		{
+			name:           "if-modified-since not modified",
+			method:         "GET",
+			path:           "/data",
+			headers:        map[string]string{"If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)},
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   etag,
+		},
+		{
+			name:           "if-modified-since modified",
+			method:         "GET",
+			path:           "/data",
+			headers:        map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "{\"msg\":\"hi\"}\n",
+			expectedETag:   etag,
+		},
+		{
+			name:           "handler etag is kept",
+			method:         "GET",
+			path:           "/tagged",
+			headers:        map[string]string{"If-None-Match": `"v1"`},
+			expectedStatus: http.StatusNotModified,
+			expectedETag:   `"v1"`,
+		},
+		{
+			name:           "error responses are not tagged",
+			method:         "GET",
+			path:           "/missing",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "missing",
+		},
+		{
+			name:           "unsafe methods pass through",
+			method:         "POST",
+			path:           "/data",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "posted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+
+			newEngine(tt.weak).ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+			if got := rr.Header().Get("ETag"); got != tt.expectedETag {
+				t.Errorf("Expected etag %q, got %q", tt.expectedETag, got)
+			}
+		})
+	}
+}
+
+func TestContext_CacheHelpers(t *testing.T) {
+	rr := httptest.NewRecorder()
+	c := newContext(rr, httptest.NewRequest("GET", "/", nil))
+
+	c.CacheControl("private", "no-cache")
+	c.LastModified(time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600)))
+
+	if got := rr.Header().Get("Cache-Control"); got != "private, no-cache" {
+		t.Errorf("Expected Cache-Control %q, got %q", "private, no-cache", got)
+	}
+	if got := rr.Header().Get("Last-Modified"); got != "Tue, 02 Jan 2024 02:04:05 GMT" {
+		t.Errorf("Expected Last-Modified in GMT, got %q", got)
+	}
+}
@@ -1,8 +1,13 @@
 package gee
 
 import (
+	"fmt"
+	"net"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type Handler func(c *Context)
@@ -10,60 +15,216 @@ type Handler func(c *Context)
 type Engine struct {
 	*RouteGroup
 	router *router
+
+	// mu serializes registration. ServeHTTP never takes it, it reads state
+	// and the routers' tables, which writers replace instead of modifying.
+	mu     sync.Mutex
+	state  atomic.Pointer[serveState]
 	groups []*RouteGroup
+	hosts  []*hostRouter
+
+	trustedCIDRs []*net.IPNet
+	namedRoutes  map[string]*Route
+	routes       []*Route
+
+	// MaxMultipartMemory is the part of a multipart body kept in memory,
+	// the rest is spooled to temp files.
+	MaxMultipartMemory int64
+	// MaxBodyBytes caps the request body, 0 means no limit.
+	MaxBodyBytes int64
+
+	// RedirectTrailingSlash redirects /foo/ to /foo, or the other way round,
+	// when only the other form is registered.
+	RedirectTrailingSlash bool
+	// RedirectFixedPath redirects paths with "..", duplicate slashes or the
+	// wrong case to the registered route.
+	RedirectFixedPath bool
+	// UseRawPath routes on the escaped path, so %2F in a segment is not
+	// treated as a separator.
+	UseRawPath bool
+	// UnescapePathValues unescapes params matched on the raw path.
+	UnescapePathValues bool
+
+	// H2C serves HTTP/2 without TLS, both to clients with prior knowledge
+	// and to ones sending Upgrade: h2c. Upgrade requests with a body are
+	// answered over HTTP/1.1.
+	H2C bool
+
+	servers       []*http.Server
+	shutdownHooks []func()
+	shuttingDown  atomic.Bool
 }
 
 func New() *Engine {
-	e := &Engine{router: newRouter()}
+	e := &Engine{router: newRouter(), MaxMultipartMemory: defaultMultipartMemory, UnescapePathValues: true}
 	e.RouteGroup = &RouteGroup{
 		prefix:   "",
 		handlers: nil,
 		engine:   e,
 	}
 	e.groups = append(e.groups, e.RouteGroup)
+	e.publish()
 
 	return e
 }
 
 func Default() *Engine {
-	e := &Engine{router: newRouter()}
+	e := &Engine{router: newRouter(), MaxMultipartMemory: defaultMultipartMemory, UnescapePathValues: true}
 	e.RouteGroup = &RouteGroup{
 		prefix:   "",
 		handlers: HandlerChain{Recovery()},
 		engine:   e,
 	}
 	e.groups = append(e.groups, e.RouteGroup)
+	e.publish()
 
 	return e
 }
 
-func (e *Engine) addRoute(method, pattern string, handler Handler) {
-	e.router.addRoute(method, pattern, handler)
-}
+// maxChainLength bounds group middleware plus route handlers of one route.
+const maxChainLength = 64
 
-func (e *Engine) GET(pattern string, handler Handler) {
-	e.addRoute("GET", pattern, handler)
+func (e *Engine) addHostRoute(host *hostRouter, method, pattern string, handlers HandlerChain) *Route {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(handlers) == 0 {
+		panic(fmt.Sprintf("gee: route %s %s has no handler", method, pattern))
+	}
+	if n := len(e.state.Load().groupHandlers(host, pattern)) + len(handlers); n > maxChainLength {
+		panic(fmt.Sprintf("gee: route %s %s has %d handlers, more than %d", method, pattern, n, maxChainLength))
+	}
+
+	if host != nil {
+		host.router.addRoute(method, pattern, handlers...)
+	} else {
+		e.router.addRoute(method, pattern, handlers...)
+	}
+
+	rt := &Route{method: method, pattern: pattern, handlers: handlers, host: host, engine: e}
+	for i, old := range e.routes {
+		if old.method == method && old.pattern == pattern && old.host == host {
+			// the replacement keeps the name, URLFor and RemoveRoute go
+			// through it
+			if old.name != "" {
+				rt.name = old.name
+				e.namedRoutes[old.name] = rt
+			}
+			e.routes[i] = rt
+			return rt
+		}
+	}
+	e.routes = append(e.routes, rt)
+	return rt
 }
 
-func (e *Engine) POST(pattern string, handler Handler) {
-	e.addRoute("POST", pattern, handler)
+// Server returns the http.Server Run and RunTLS use, for callers that want
+// to set timeouts or shut down gracefully. HTTP/2 is negotiated over TLS.
+func (e *Engine) Server(addr string) *http.Server {
+	srv := &http.Server{Addr: addr, Handler: e, Protocols: new(http.Protocols)}
+	srv.Protocols.SetHTTP1(true)
+	srv.Protocols.SetHTTP2(true)
+	srv.Protocols.SetUnencryptedHTTP2(e.H2C)
+	if e.H2C {
+		srv.Handler = newH2CUpgrader(e, srv)
+	}
+	srv.RegisterOnShutdown(e.beginShutdown)
+
+	e.mu.Lock()
+	e.servers = append(e.servers, srv)
+	e.mu.Unlock()
+
+	return srv
 }
 
 func (e *Engine) Run(addr string) error {
-	return http.ListenAndServe(addr, e)
+	return e.Server(addr).ListenAndServe()
+}
+
+func (e *Engine) RunTLS(addr, certFile, keyFile string) error {
+	return e.Server(addr).ListenAndServeTLS(certFile, keyFile)
 }
 
 func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if e.MaxBodyBytes > 0 {
+		if r.ContentLength > e.MaxBodyBytes {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, e.MaxBodyBytes)
+	}
+
+	c := newContext(newResponseWriter(w), r)
+	c.engine = e
+	if e.UseRawPath && r.URL.RawPath != "" {
+		c.path = r.URL.RawPath
+	}
+
+	state := e.state.Load()
+	host, hostParams := state.matchHost(r.Host)
+	for k, v := range hostParams {
+		c.params[k] = v
+	}
+
+	router := e.router
+	if host != nil {
+		router = host.router
+	}
+	if e.redirectPath(c, router) {
+		return
+	}
+
+	c.handlers = state.groupHandlers(host, r.URL.Path)
+	router.handle(c)
+}
+
+// serveState is what ServeHTTP needs of the hosts and groups, copied so
+// that registering a group or middleware never touches a published one.
+type serveState struct {
+	hosts  []*hostRouter
+	groups []groupState
+}
+
+type groupState struct {
+	prefix   string
+	host     *hostRouter
+	root     bool
+	handlers HandlerChain
+}
+
+// publish swaps in a fresh serveState, the caller holds e.mu.
+func (e *Engine) publish() {
+	state := &serveState{
+		hosts:  slices.Clone(e.hosts),
+		groups: make([]groupState, 0, len(e.groups)),
+	}
+	for _, g := range e.groups {
+		state.groups = append(state.groups, groupState{
+			prefix:   g.prefix,
+			host:     g.host,
+			root:     g == e.RouteGroup,
+			handlers: slices.Clone(g.handlers),
+		})
+	}
+
+	e.state.Store(state)
+}
+
+// groupHandlers collects the middleware of every group whose prefix
+// matches path. Groups only apply to their own host, except the engine's
+// root group which applies everywhere.
+func (s *serveState) groupHandlers(host *hostRouter, path string) HandlerChain {
 	var handlers HandlerChain
-	for _, group := range e.groups {
-		if group != nil && strings.HasPrefix(r.URL.Path, group.prefix) {
+	for _, group := range s.groups {
+		if group.host != host && !group.root {
+			continue
+		}
+		if strings.HasPrefix(path, group.prefix) {
 			handlers = append(handlers, group.handlers...)
 		}
 	}
 
-	c := newContext(w, r)
-	c.handlers = handlers
-	e.router.handle(c)
+	return handlers
 }
 
 type HandlerChain []Handler
@@ -71,6 +232,7 @@ type HandlerChain []Handler
 type RouteGroup struct {
 	prefix   string
 	handlers HandlerChain
+	host     *hostRouter
 	engine   *Engine
 }
 
@@ -79,26 +241,86 @@ func (g *RouteGroup) Group(prefix string) *RouteGroup {
 	newGroup := &RouteGroup{
 		prefix:   g.prefix + prefix,
 		handlers: nil,
+		host:     g.host,
 		engine:   e,
 	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.groups = append(e.groups, newGroup)
+	e.publish()
 
 	return newGroup
 }
 
 func (g *RouteGroup) Use(handlers ...Handler) {
+	e := g.engine
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	g.handlers = append(g.handlers, handlers...)
+	e.publish()
 }
 
-func (g *RouteGroup) addRoute(method, pattern string, handler Handler) {
+// RemoveRoute unregisters a route of the group, also while requests are
+// being served; requests already routed finish with the old handlers. It
+// reports whether the route was registered.
+func (g *RouteGroup) RemoveRoute(method, pattern string) bool {
+	e := g.engine
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	pattern = g.prefix + pattern
-	g.engine.router.addRoute(method, pattern, handler)
+	router := e.router
+	if g.host != nil {
+		router = g.host.router
+	}
+	if !router.removeRoute(method, pattern) {
+		return false
+	}
+
+	e.routes = slices.DeleteFunc(e.routes, func(rt *Route) bool {
+		if rt.method != method || rt.pattern != pattern || rt.host != g.host {
+			return false
+		}
+		if rt.name != "" {
+			delete(e.namedRoutes, rt.name)
+		}
+		return true
+	})
+	return true
+}
+
+func (g *RouteGroup) addRoute(method, pattern string, handlers ...Handler) *Route {
+	return g.engine.addHostRoute(g.host, method, g.prefix+pattern, handlers)
+}
+
+// GET and its siblings register a route; every handler but the last acts
+// as route-level middleware that runs after the group chain.
+func (g *RouteGroup) GET(pattern string, handlers ...Handler) *Route {
+	return g.addRoute(http.MethodGet, pattern, handlers...)
+}
+
+func (g *RouteGroup) POST(pattern string, handlers ...Handler) *Route {
+	return g.addRoute(http.MethodPost, pattern, handlers...)
+}
+
+func (g *RouteGroup) PUT(pattern string, handlers ...Handler) *Route {
+	return g.addRoute(http.MethodPut, pattern, handlers...)
+}
+
+func (g *RouteGroup) PATCH(pattern string, handlers ...Handler) *Route {
+	return g.addRoute(http.MethodPatch, pattern, handlers...)
+}
+
+func (g *RouteGroup) DELETE(pattern string, handlers ...Handler) *Route {
+	return g.addRoute(http.MethodDelete, pattern, handlers...)
 }
 
-func (g *RouteGroup) GET(pattern string, handler Handler) {
-	g.addRoute("GET", pattern, handler)
+func (g *RouteGroup) HEAD(pattern string, handlers ...Handler) *Route {
+	return g.addRoute(http.MethodHead, pattern, handlers...)
 }
 
-func (g *RouteGroup) POST(pattern string, handler Handler) {
-	g.addRoute("POST", pattern, handler)
+func (g *RouteGroup) OPTIONS(pattern string, handlers ...Handler) *Route {
+	return g.addRoute(http.MethodOptions, pattern, handlers...)
 }
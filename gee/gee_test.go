@@ -1,8 +1,10 @@
 package gee
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 )
 
@@ -17,8 +19,8 @@ func TestEngine_New(t *testing.T) {
 		t.Fatal("New() router is nil")
 	}
 
-	if len(newEngine.router.handlers) != 0 {
-		t.Errorf("Expected empty router, got %d routes", len(newEngine.router.handlers))
+	if len(newEngine.router.load().handlers) != 0 {
+		t.Errorf("Expected empty router, got %d routes", len(newEngine.router.load().handlers))
 	}
 }
 
@@ -33,7 +35,7 @@ func TestEngine_Get(t *testing.T) {
 	e.GET("/hello", handler)
 
 	key := "GET_/hello"
-	if _, ok := e.router.handlers[key]; !ok {
+	if _, ok := e.router.load().handlers[key]; !ok {
 		t.Errorf("GET route %s not found in router", key)
 	}
 }
@@ -49,7 +51,7 @@ func TestEngine_Post(t *testing.T) {
 	e.POST("/users", handler)
 
 	key := "POST_/users"
-	if _, ok := e.router.handlers[key]; !ok {
+	if _, ok := e.router.load().handlers[key]; !ok {
 		t.Errorf("POST route %s not found in router", key)
 	}
 }
@@ -195,3 +197,148 @@ func TestEngine_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestEngine_RemoveRoute(t *testing.T) {
+	e := New()
+	api := e.Group("/api")
+	api.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "user") }).Name("user")
+	api.GET("/health", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		name           string
+		remove         func() bool
+		expectedRemove bool
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "group route",
+			remove:         func() bool { return api.RemoveRoute("GET", "/users/:id") },
+			expectedRemove: true,
+			path:           "/api/users/1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "engine with full pattern",
+			remove:         func() bool { return e.RemoveRoute("GET", "/api/health") },
+			expectedRemove: true,
+			path:           "/api/health",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "already removed",
+			remove:         func() bool { return e.RemoveRoute("GET", "/api/health") },
+			expectedRemove: false,
+			path:           "/api/health",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if removed := tt.remove(); removed != tt.expectedRemove {
+				t.Errorf("Expected removed %v, got %v", tt.expectedRemove, removed)
+			}
+
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+
+	if len(e.Routes()) != 0 {
+		t.Errorf("Expected no routes left, got %v", e.Routes())
+	}
+	if _, err := e.URLFor("user", "id", "1"); err == nil {
+		t.Errorf("Expected the route name to be released")
+	}
+}
+
+func TestEngine_RemoveReplacedNamedRoute(t *testing.T) {
+	e := New()
+	e.GET("/a", func(c *Context) {}).Name("a")
+	e.GET("/a", func(c *Context) {})
+
+	if path, err := e.URLFor("a"); err != nil || path != "/a" {
+		t.Errorf("Expected the replacement to keep the name, got %q, %v", path, err)
+	}
+
+	e.RemoveRoute("GET", "/a")
+	if _, err := e.URLFor("a"); err == nil {
+		t.Errorf("Expected the route name to be released")
+	}
+}
+
+// run with -race: registration and removal must not race with serving
+func TestEngine_ConcurrentRegistration(t *testing.T) {
+	e := New()
+	e.GET("/stable", func(c *Context) { c.String(http.StatusOK, "stable") })
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Go(func() {
+			for j := range 50 {
+				g := e.Group(fmt.Sprintf("/plugin%d", i))
+				g.Use(func(c *Context) { c.Next() })
+				pattern := fmt.Sprintf("/r%d", j)
+				g.GET(pattern, func(c *Context) { c.String(http.StatusOK, "plugin") })
+				if j%2 == 0 {
+					g.RemoveRoute("GET", pattern)
+				}
+			}
+		})
+		wg.Go(func() {
+			for range 200 {
+				rr := httptest.NewRecorder()
+				e.ServeHTTP(rr, httptest.NewRequest("GET", "/stable", nil))
+				if rr.Code != http.StatusOK {
+					t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+				}
+				e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", fmt.Sprintf("/plugin%d/r1", i), nil))
+			}
+		})
+	}
+	wg.Wait()
+
+	if n := len(e.Routes()); n != 1+4*25 {
+		t.Errorf("Expected %d routes, got %d", 1+4*25, n)
+	}
+}
+
+func TestEngine_RemoveRouteKeepsOtherSlashForm(t *testing.T) {
+	tests := []struct {
+		remove       string
+		expectedGone string
+		expectedKept string
+	}{
+		{remove: "/hello/", expectedGone: "/hello/", expectedKept: "/hello"},
+		{remove: "/hello", expectedGone: "/hello", expectedKept: "/hello/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remove, func(t *testing.T) {
+			e := New()
+			e.GET("/hello", func(c *Context) { c.String(http.StatusOK, "/hello") })
+			e.GET("/hello/", func(c *Context) { c.String(http.StatusOK, "/hello/") })
+
+			if !e.RemoveRoute("GET", tt.remove) {
+				t.Fatalf("Expected %s to be removed", tt.remove)
+			}
+
+			for _, path := range []string{"/hello", "/hello/"} {
+				rr := httptest.NewRecorder()
+				e.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+				if rr.Code != http.StatusOK || rr.Body.String() != tt.expectedKept {
+					t.Errorf("Expected %s to be served by %s, got %d %q", path, tt.expectedKept, rr.Code, rr.Body.String())
+				}
+			}
+
+			routes := e.Routes()
+			if len(routes) != 1 || routes[0].Pattern != tt.expectedKept {
+				t.Errorf("Expected only %s to be listed, got %+v", tt.expectedKept, routes)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// Package geetest runs requests through a gee Engine in memory and asserts
+// on the responses, replacing the httptest boilerplate in handler tests.
+package geetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/gee"
+)
+
+type Client struct {
+	handler http.Handler
+}
+
+// New wraps an Engine, or any other http.Handler, for in-memory requests.
+func New(handler http.Handler) *Client {
+	return &Client{handler: handler}
+}
+
+type Request struct {
+	client  *Client
+	method  string
+	path    string
+	query   url.Values
+	header  http.Header
+	cookies []*http.Cookie
+	body    []byte
+	err     error
+}
+
+func (cl *Client) Request(method, path string) *Request {
+	return &Request{
+		client: cl,
+		method: method,
+		path:   path,
+		query:  make(url.Values),
+		header: make(http.Header),
+	}
+}
+
+func (cl *Client) GET(path string) *Request    { return cl.Request(http.MethodGet, path) }
+func (cl *Client) POST(path string) *Request   { return cl.Request(http.MethodPost, path) }
+func (cl *Client) PUT(path string) *Request    { return cl.Request(http.MethodPut, path) }
+func (cl *Client) PATCH(path string) *Request  { return cl.Request(http.MethodPatch, path) }
+func (cl *Client) DELETE(path string) *Request { return cl.Request(http.MethodDelete, path) }
+
+func (r *Request) Header(key, value string) *Request {
+	r.header.Add(key, value)
+	return r
+}
+
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+func (r *Request) Cookie(cookie *http.Cookie) *Request {
+	r.cookies = append(r.cookies, cookie)
+	return r
+}
+
+func (r *Request) Body(body string) *Request {
+	r.body = []byte(body)
+	return r
+}
+
+// JSON encodes v as the request body and sets the Content-Type.
+func (r *Request) JSON(v any) *Request {
+	r.body, r.err = json.Marshal(v)
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+// Form sends values as an urlencoded form body.
+func (r *Request) Form(values url.Values) *Request {
+	r.body = []byte(values.Encode())
+	r.header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// Do runs the request through the handler and returns the recorded
+// response. Assertion failures are reported on t.
+func (r *Request) Do(t testing.TB) *Response {
+	t.Helper()
+	if r.err != nil {
+		t.Fatalf("geetest: building %s %s: %v", r.method, r.path, r.err)
+	}
+
+	target := r.path
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	req := httptest.NewRequest(r.method, target, body)
+	for k, vs := range r.header {
+		req.Header[k] = vs
+	}
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(rec, req)
+
+	return &Response{ResponseRecorder: rec, t: t}
+}
+
+type Response struct {
+	*httptest.ResponseRecorder
+	t testing.TB
+}
+
+func (r *Response) Status(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Errorf("Expected status code %d, got %d (body %q)", code, r.Code, r.ResponseRecorder.Body.String())
+	}
+	return r
+}
+
+func (r *Response) Header(key, value string) *Response {
+	r.t.Helper()
+	if got := r.Result().Header.Get(key); got != value {
+		r.t.Errorf("Expected header %s %q, got %q", key, value, got)
+	}
+	return r
+}
+
+func (r *Response) Body(body string) *Response {
+	r.t.Helper()
+	if got := r.ResponseRecorder.Body.String(); got != body {
+		r.t.Errorf("Expected body %q, got %q", body, got)
+	}
+	return r
+}
+
+func (r *Response) BodyContains(substr string) *Response {
+	r.t.Helper()
+	if got := r.ResponseRecorder.Body.String(); !strings.Contains(got, substr) {
+		r.t.Errorf("Expected body to contain %q, got %q", substr, got)
+	}
+	return r
+}
+
+// DecodeJSON unmarshals the response body into v.
+func (r *Response) DecodeJSON(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.ResponseRecorder.Body.Bytes(), v); err != nil {
+		r.t.Errorf("Decoding JSON body %q: %v", r.ResponseRecorder.Body.String(), err)
+	}
+	return r
+}
+
+// JSONPath asserts that the value at a dot separated path of the JSON body,
+// such as "data.users.0.name", equals expected once both sides are
+// normalized through encoding/json.
+func (r *Response) JSONPath(path string, expected any) *Response {
+	r.t.Helper()
+
+	var doc any
+	if err := json.Unmarshal(r.ResponseRecorder.Body.Bytes(), &doc); err != nil {
+		r.t.Errorf("Decoding JSON body %q: %v", r.ResponseRecorder.Body.String(), err)
+		return r
+	}
+
+	got, err := lookupJSONPath(doc, path)
+	if err != nil {
+		r.t.Errorf("JSON path %q: %v", path, err)
+		return r
+	}
+
+	want, err := normalizeJSON(expected)
+	if err != nil {
+		r.t.Errorf("JSON path %q: encoding expected value: %v", path, err)
+		return r
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		r.t.Errorf("Expected JSON path %q = %v, got %v", path, want, got)
+	}
+	return r
+}
+
+func lookupJSONPath(doc any, path string) (any, error) {
+	if path == "" {
+		return doc, nil
+	}
+
+	cur := doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[key]
+			if !ok {
+				return nil, &pathError{key: key, reason: "no such key"}
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, &pathError{key: key, reason: "index out of range"}
+			}
+			cur = v[i]
+		default:
+			return nil, &pathError{key: key, reason: "not an object or array"}
+		}
+	}
+
+	return cur, nil
+}
+
+type pathError struct {
+	key    string
+	reason string
+}
+
+func (e *pathError) Error() string {
+	return e.key + ": " + e.reason
+}
+
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out any
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// CreateTestContext builds a Context for unit-testing a single handler or
+// middleware outside the router. Calling c.Next() runs handlers in order;
+// the returned Engine can be configured before that, e.g. SetTrustedProxies.
+func CreateTestContext(w http.ResponseWriter, r *http.Request, handlers ...gee.Handler) (*gee.Context, *gee.Engine) {
+	e := gee.New()
+	return e.NewContext(w, r, handlers...), e
+}
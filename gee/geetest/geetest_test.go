@@ -0,0 +1,138 @@
+package geetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loveRyujin/gee"
+)
+
+func TestMain(m *testing.M) {
+	gee.SetMode(gee.TestMode)
+	m.Run()
+}
+
+func newTestEngine() *gee.Engine {
+	e := gee.New()
+	e.GET("/hello/:name", func(c *gee.Context) {
+		c.SetHeader("X-Lang", c.Query("lang"))
+		c.String(http.StatusOK, "hello %s", c.Param("name"))
+	})
+	e.POST("/echo", func(c *gee.Context) {
+		cookie, _ := c.Cookie("session")
+		c.JSON(http.StatusCreated, gee.H{
+			"session": cookie,
+			"auth":    c.GetHeader("Authorization"),
+			"user":    gee.H{"name": c.PostForm("name"), "tags": []string{"a", "b"}},
+		})
+	})
+	return e
+}
+
+func TestClient_GET(t *testing.T) {
+	New(newTestEngine()).
+		GET("/hello/gee").
+		Query("lang", "go").
+		Do(t).
+		Status(http.StatusOK).
+		Header("X-Lang", "go").
+		Body("hello gee").
+		BodyContains("gee")
+}
+
+func TestClient_POST_JSONPath(t *testing.T) {
+	New(newTestEngine()).
+		POST("/echo").
+		Body("name=geektutu").
+		Header("Content-Type", "application/x-www-form-urlencoded").
+		Header("Authorization", "Bearer token").
+		Cookie(&http.Cookie{Name: "session", Value: "s1"}).
+		Do(t).
+		Status(http.StatusCreated).
+		JSONPath("session", "s1").
+		JSONPath("auth", "Bearer token").
+		JSONPath("user.name", "geektutu").
+		JSONPath("user.tags.1", "b").
+		JSONPath("user.tags", []string{"a", "b"})
+}
+
+func TestResponse_ReportsFailures(t *testing.T) {
+	ft := &fakeT{TB: t}
+	New(newTestEngine()).
+		GET("/hello/gee").
+		Do(ft).
+		Status(http.StatusTeapot).
+		Body("nope").
+		JSONPath("missing", 1)
+
+	if ft.errors != 3 {
+		t.Errorf("Expected 3 reported failures, got %d", ft.errors)
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	doc := map[string]any{"a": []any{map[string]any{"b": 1.0}}}
+
+	tests := []struct {
+		name        string
+		path        string
+		expected    any
+		expectedErr bool
+	}{
+		{name: "nested", path: "a.0.b", expected: 1.0},
+		{name: "missing key", path: "a.0.c", expectedErr: true},
+		{name: "bad index", path: "a.5", expectedErr: true},
+		{name: "through scalar", path: "a.0.b.c", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lookupJSONPath(doc, tt.path)
+
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if !tt.expectedErr && got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCreateTestContext(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users/1", nil)
+
+	var order []string
+	c, _ := CreateTestContext(rr, req,
+		func(c *gee.Context) {
+			order = append(order, "middleware")
+			c.Next()
+		},
+		func(c *gee.Context) {
+			order = append(order, "handler")
+			c.String(http.StatusOK, "user %s", c.Param("id"))
+		},
+	)
+	c.AddParam("id", "1")
+	c.Next()
+
+	if len(order) != 2 || order[0] != "middleware" || order[1] != "handler" {
+		t.Errorf("Unexpected execution order %v", order)
+	}
+	if rr.Body.String() != "user 1" {
+		t.Errorf("Expected body %q, got %q", "user 1", rr.Body.String())
+	}
+}
+
+type fakeT struct {
+	testing.TB
+	errors int
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors++
+}
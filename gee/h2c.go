@@ -0,0 +1,228 @@
+package gee
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	h2cPreface      = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+	h2cMaxFrameSize = 16384 // the size every HTTP/2 peer has to accept
+)
+
+// h2cUpgrader answers Upgrade: h2c requests. net/http only speaks h2c to
+// clients with prior knowledge, so after the 101 the connection is handed
+// back to the http.Server through h2cListener with the upgraded request
+// replayed as stream 1. The server then serves it with its own HTTP/2
+// support, and Shutdown drains it like any other connection.
+type h2cUpgrader struct {
+	engine *Engine
+	ln     *h2cListener
+}
+
+func newH2CUpgrader(e *Engine, srv *http.Server) *h2cUpgrader {
+	ln := &h2cListener{srv: srv, conns: make(chan net.Conn), done: make(chan struct{})}
+	srv.RegisterOnShutdown(func() { ln.Close() })
+	return &h2cUpgrader{engine: e, ln: ln}
+}
+
+func (u *h2cUpgrader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the upgrade is optional, anything it can't take is served over HTTP/1.1
+	block, ok := h2cUpgradeHeaders(r)
+	if !ok || u.engine.ShuttingDown() {
+		u.engine.ServeHTTP(w, r)
+		return
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		u.engine.ServeHTTP(w, r)
+		return
+	}
+
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n")
+	settings, err := readH2CPreface(brw.Reader)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	replay := io.MultiReader(bytes.NewReader(settings), bytes.NewReader(h2cFrame(0x1, 0x1|0x4, 1, block)), brw.Reader)
+	u.ln.serve(&h2cConn{Conn: conn, r: replay})
+}
+
+// h2cUpgradeHeaders checks that r is an upgrade request without a body and
+// HPACK-encodes it for stream 1. The client's SETTINGS frame is used rather
+// than the HTTP2-Settings header, which only has to be valid.
+func h2cUpgradeHeaders(r *http.Request) ([]byte, bool) {
+	if r.ProtoMajor != 1 || r.ContentLength != 0 || len(r.TransferEncoding) > 0 {
+		return nil, false
+	}
+	if !headerHasToken(r.Header, "Upgrade", "h2c") || !headerHasToken(r.Header, "Connection", "upgrade") ||
+		!headerHasToken(r.Header, "Connection", "http2-settings") {
+		return nil, false
+	}
+	settings := r.Header.Values("HTTP2-Settings")
+	if len(settings) != 1 {
+		return nil, false
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(settings[0], "=")); err != nil {
+		return nil, false
+	}
+
+	hopByHop := map[string]bool{
+		"connection": true, "upgrade": true, "http2-settings": true, "keep-alive": true,
+		"proxy-connection": true, "transfer-encoding": true, "te": true, "host": true,
+	}
+	for _, value := range r.Header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			hopByHop[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+
+	block := appendHPACKField(nil, ":method", r.Method)
+	block = appendHPACKField(block, ":scheme", "http")
+	block = appendHPACKField(block, ":authority", r.Host)
+	block = appendHPACKField(block, ":path", r.URL.RequestURI())
+	for name, values := range r.Header {
+		name = strings.ToLower(name)
+		if hopByHop[name] {
+			continue
+		}
+		for _, value := range values {
+			block = appendHPACKField(block, name, value)
+		}
+	}
+	if len(block) > h2cMaxFrameSize {
+		return nil, false
+	}
+
+	return block, true
+}
+
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readH2CPreface reads the client preface and the SETTINGS frame that must
+// follow it, and returns both to be replayed ahead of stream 1.
+func readH2CPreface(r io.Reader) ([]byte, error) {
+	buf := make([]byte, len(h2cPreface)+9)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	header := buf[len(h2cPreface):]
+	if string(buf[:len(h2cPreface)]) != h2cPreface || header[3] != 0x4 {
+		return nil, errors.New("gee: invalid h2c preface")
+	}
+
+	length := int(header[0])<<16 | int(header[1])<<8 | int(header[2])
+	if length > h2cMaxFrameSize {
+		return nil, errors.New("gee: h2c SETTINGS frame too large")
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	return append(buf, payload...), nil
+}
+
+func h2cFrame(typ, flags byte, stream uint32, payload []byte) []byte {
+	n := len(payload)
+	frame := []byte{byte(n >> 16), byte(n >> 8), byte(n), typ, flags,
+		byte(stream >> 24), byte(stream >> 16), byte(stream >> 8), byte(stream)}
+	return append(frame, payload...)
+}
+
+// appendHPACKField adds a literal field without indexing, so no dynamic
+// table state is shared with the server's decoder.
+func appendHPACKField(b []byte, name, value string) []byte {
+	b = append(b, 0)
+	b = appendHPACKString(b, name)
+	return appendHPACKString(b, value)
+}
+
+func appendHPACKString(b []byte, s string) []byte {
+	// length with a 7-bit prefix, the high bit off for no Huffman coding
+	n := len(s)
+	if n < 127 {
+		b = append(b, byte(n))
+	} else {
+		b = append(b, 127)
+		for n -= 127; n >= 128; n >>= 7 {
+			b = append(b, byte(n&0x7f|0x80))
+		}
+		b = append(b, byte(n))
+	}
+	return append(b, s...)
+}
+
+// h2cConn reads the replayed preface and stream 1 before the rest of the
+// connection.
+type h2cConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *h2cConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// h2cListener passes upgraded connections to the http.Server. It is served
+// on first use and closed together with the server.
+type h2cListener struct {
+	srv       *http.Server
+	conns     chan net.Conn
+	serveOnce sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func (l *h2cListener) serve(conn net.Conn) {
+	l.serveOnce.Do(func() { go l.srv.Serve(l) })
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+func (l *h2cListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *h2cListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *h2cListener) Addr() net.Addr {
+	return h2cAddr{}
+}
+
+type h2cAddr struct{}
+
+func (h2cAddr) Network() string { return "h2c-upgrade" }
+func (h2cAddr) String() string  { return "h2c-upgrade" }
@@ -0,0 +1,232 @@
+// Package health serves liveness and readiness probes backed by registered
+// checks.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/loveRyujin/gee"
+)
+
+const defaultTimeout = 5 * time.Second
+
+const (
+	StatusOK           = "ok"
+	StatusDegraded     = "degraded"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+type Options struct {
+	// Timeout bounds one run of the check, 5s by default.
+	Timeout time.Duration
+	// CacheTTL reuses the last result for this long, so frequent probes
+	// don't hammer a dependency. 0 runs the check on every probe.
+	CacheTTL time.Duration
+	// NonCritical checks are reported but only degrade the status, the
+	// probe keeps answering 200.
+	NonCritical bool
+	// Liveness also runs the check on /healthz. A failing liveness probe
+	// gets the process restarted, so only use it for what a restart fixes.
+	Liveness bool
+}
+
+type CheckResult struct {
+	Status    string        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Cached    bool          `json:"cached,omitempty"`
+}
+
+type Report struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+type check struct {
+	name string
+	fn   func(ctx context.Context) error
+	opts Options
+
+	mu   sync.Mutex // held while running, so concurrent probes share a cached result
+	last *CheckResult
+}
+
+type Health struct {
+	mu           sync.RWMutex
+	checks       []*check
+	shuttingDown atomic.Bool
+}
+
+func New() *Health {
+	return &Health{}
+}
+
+// Use serves /healthz and /readyz on e and fails readiness as soon as e
+// begins a graceful shutdown.
+func Use(e *gee.Engine) *Health {
+	h := New()
+	e.OnShutdown(h.Shutdown)
+	e.GET("/healthz", h.LiveHandler())
+	e.GET("/readyz", h.ReadyHandler())
+	return h
+}
+
+// Register adds a check. Registering a name again replaces the check.
+func (h *Health) Register(name string, fn func(ctx context.Context) error, opts Options) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := &check{name: name, fn: fn, opts: opts}
+	for i, old := range h.checks {
+		if old.name == name {
+			h.checks[i] = c
+			return
+		}
+	}
+	h.checks = append(h.checks, c)
+}
+
+// Shutdown makes readiness fail from now on.
+func (h *Health) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live reports the liveness checks, Ready all of them. Checks run
+// concurrently.
+func (h *Health) Live(ctx context.Context) *Report {
+	return h.run(ctx, true)
+}
+
+func (h *Health) Ready(ctx context.Context) *Report {
+	if h.shuttingDown.Load() {
+		return &Report{Status: StatusShuttingDown}
+	}
+	return h.run(ctx, false)
+}
+
+func (h *Health) LiveHandler() gee.Handler {
+	return func(c *gee.Context) {
+		writeReport(c, h.Live(c.Request().Context()))
+	}
+}
+
+func (h *Health) ReadyHandler() gee.Handler {
+	return func(c *gee.Context) {
+		writeReport(c, h.Ready(c.Request().Context()))
+	}
+}
+
+func writeReport(c *gee.Context, report *Report) {
+	code := http.StatusOK
+	if report.Status == StatusFail || report.Status == StatusShuttingDown {
+		code = http.StatusServiceUnavailable
+	}
+	c.SetHeader("Cache-Control", "no-store")
+	c.JSON(code, report)
+}
+
+func (h *Health) run(ctx context.Context, liveOnly bool) *Report {
+	h.mu.RLock()
+	checks := make([]*check, 0, len(h.checks))
+	for _, c := range h.checks {
+		if !liveOnly || c.opts.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	results := make([]*CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Go(func() {
+			results[i] = c.result(ctx)
+		})
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, Checks: make(map[string]*CheckResult, len(checks))}
+	for i, c := range checks {
+		res := results[i]
+		report.Checks[c.name] = res
+		if res.Status == StatusOK {
+			continue
+		}
+		if res.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (c *check) result(ctx context.Context) *CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && c.opts.CacheTTL > 0 && time.Since(c.last.CheckedAt) < c.opts.CacheTTL {
+		cached := *c.last
+		cached.Cached = true
+		return &cached
+	}
+
+	start := time.Now()
+	err := c.runWithTimeout(ctx)
+	res := &CheckResult{
+		Status:    StatusOK,
+		Critical:  !c.opts.NonCritical,
+		Duration:  time.Since(start),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	// a probe cancelled by its own client says nothing about the check
+	if ctx.Err() == nil {
+		c.last = res
+	}
+	return res
+}
+
+// runWithTimeout stops waiting once the timeout passes even if fn ignores
+// its context; fn then finishes in the background.
+func (c *check) runWithTimeout(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", c.opts.Timeout)
+		}
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,191 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/loveRyujin/gee"
+)
+
+func probe(t *testing.T, e *gee.Engine, path string) (int, *Report) {
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+
+	var report Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Expected a JSON report, got %q", rr.Body.String())
+	}
+	return rr.Code, &report
+}
+
+func TestHealth_Report(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	stuck := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	tests := []struct {
+		name           string
+		register       func(h *Health)
+		path           string
+		expectedStatus int
+		expectedReport string
+		expectedChecks map[string]string
+	}{
+		{
+			name:           "no checks",
+			register:       func(h *Health) {},
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+			expectedReport: StatusOK,
+		},
+		{
+			name: "all passing",
+			register: func(h *Health) {
+				h.Register("db", ok, Options{})
+				h.Register("cache", ok, Options{NonCritical: true})
+			},
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+			expectedReport: StatusOK,
+			expectedChecks: map[string]string{"db": StatusOK, "cache": StatusOK},
+		},
+		{
+			name: "non-critical failure degrades",
+			register: func(h *Health) {
+				h.Register("db", ok, Options{})
+				h.Register("cache", failing, Options{NonCritical: true})
+			},
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+			expectedReport: StatusDegraded,
+			expectedChecks: map[string]string{"db": StatusOK, "cache": StatusFail},
+		},
+		{
+			name: "critical failure fails",
+			register: func(h *Health) {
+				h.Register("db", failing, Options{})
+				h.Register("cache", failing, Options{NonCritical: true})
+			},
+			path:           "/readyz",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: StatusFail,
+			expectedChecks: map[string]string{"db": StatusFail, "cache": StatusFail},
+		},
+		{
+			name: "timeouts",
+			register: func(h *Health) {
+				h.Register("slow", slow, Options{Timeout: 10 * time.Millisecond})
+				h.Register("stuck", stuck, Options{Timeout: 10 * time.Millisecond})
+			},
+			path:           "/readyz",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: StatusFail,
+			expectedChecks: map[string]string{"slow": StatusFail, "stuck": StatusFail},
+		},
+		{
+			name: "liveness only runs liveness checks",
+			register: func(h *Health) {
+				h.Register("db", failing, Options{})
+				h.Register("deadlock", ok, Options{Liveness: true})
+			},
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+			expectedReport: StatusOK,
+			expectedChecks: map[string]string{"deadlock": StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := gee.New()
+			h := Use(e)
+			tt.register(h)
+
+			start := time.Now()
+			code, report := probe(t, e, tt.path)
+			if time.Since(start) > 500*time.Millisecond {
+				t.Errorf("Expected timeouts to bound the probe, took %s", time.Since(start))
+			}
+
+			if code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, code)
+			}
+			if report.Status != tt.expectedReport {
+				t.Errorf("Expected report status %q, got %q", tt.expectedReport, report.Status)
+			}
+			if len(report.Checks) != len(tt.expectedChecks) {
+				t.Errorf("Expected %d checks, got %d", len(tt.expectedChecks), len(report.Checks))
+			}
+			for name, status := range tt.expectedChecks {
+				res, ok := report.Checks[name]
+				if !ok || res.Status != status {
+					t.Errorf("Expected check %s %q, got %+v", name, status, res)
+					continue
+				}
+				if status == StatusFail && res.Error == "" {
+					t.Errorf("Expected check %s to report its error", name)
+				}
+			}
+		})
+	}
+}
+
+func TestHealth_CacheTTL(t *testing.T) {
+	var runs atomic.Int32
+	e := gee.New()
+	h := Use(e)
+	h.Register("db", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}, Options{CacheTTL: 50 * time.Millisecond})
+
+	_, first := probe(t, e, "/readyz")
+	_, second := probe(t, e, "/readyz")
+	if runs.Load() != 1 {
+		t.Errorf("Expected 1 run within the TTL, got %d", runs.Load())
+	}
+	if first.Checks["db"].Cached || !second.Checks["db"].Cached {
+		t.Errorf("Expected only the second result to be cached")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	probe(t, e, "/readyz")
+	if runs.Load() != 2 {
+		t.Errorf("Expected the check to run again after the TTL, got %d runs", runs.Load())
+	}
+}
+
+func TestHealth_ReadinessFailsOnShutdown(t *testing.T) {
+	e := gee.New()
+	h := Use(e)
+	h.Register("db", func(ctx context.Context) error { return nil }, Options{})
+
+	if code, _ := probe(t, e, "/readyz"); code != http.StatusOK {
+		t.Fatalf("Expected ready before shutdown, got %d", code)
+	}
+
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	code, report := probe(t, e, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != StatusShuttingDown {
+		t.Errorf("Expected 503 %q, got %d %q", StatusShuttingDown, code, report.Status)
+	}
+	if code, _ := probe(t, e, "/healthz"); code != http.StatusOK {
+		t.Errorf("Expected liveness to keep passing during shutdown, got %d", code)
+	}
+}
@@ -0,0 +1,108 @@
+package gee
+
+import (
+	"net"
+	"strings"
+)
+
+// hostRouter is the route tree of one host pattern such as
+// "admin.example.com" or ":tenant.example.com".
+type hostRouter struct {
+	pattern string
+	labels  []string
+	router  *router
+}
+
+// Host returns a group whose routes only match requests for the given host
+// pattern. A label starting with ':' captures that label as a param, so
+// ":tenant.example.com" makes c.Param("tenant") available. Requests whose
+// Host matches no pattern are served by the default tree.
+func (e *Engine) Host(pattern string) *RouteGroup {
+	// host names are case-insensitive, param names are not
+	labels := strings.Split(pattern, ".")
+	for i, label := range labels {
+		if label == "" || label[0] != ':' {
+			labels[i] = strings.ToLower(label)
+		}
+	}
+	pattern = strings.Join(labels, ".")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var host *hostRouter
+	for _, h := range e.hosts {
+		if h.pattern == pattern {
+			host = h
+			break
+		}
+	}
+	if host == nil {
+		host = &hostRouter{
+			pattern: pattern,
+			labels:  labels,
+			router:  newRouter(),
+		}
+		e.hosts = append(e.hosts, host)
+	}
+
+	group := &RouteGroup{host: host, engine: e}
+	e.groups = append(e.groups, group)
+	e.publish()
+	return group
+}
+
+// matchHost picks the host tree for a request Host. Patterns without params
+// win over wildcard ones, otherwise the first registered match is used.
+func (s *serveState) matchHost(requestHost string) (*hostRouter, map[string]string) {
+	if len(s.hosts) == 0 {
+		return nil, nil
+	}
+
+	if h, _, err := net.SplitHostPort(requestHost); err == nil {
+		requestHost = h
+	}
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(requestHost), "."), ".")
+
+	var wild *hostRouter
+	var wildParams map[string]string
+	for _, host := range s.hosts {
+		params, ok := host.match(labels)
+		if !ok {
+			continue
+		}
+		if len(params) == 0 {
+			return host, nil
+		}
+		if wild == nil {
+			wild, wildParams = host, params
+		}
+	}
+
+	return wild, wildParams
+}
+
+func (h *hostRouter) match(labels []string) (map[string]string, bool) {
+	if len(labels) != len(h.labels) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, label := range h.labels {
+		if len(label) > 1 && label[0] == ':' {
+			if labels[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[label[1:]] = labels[i]
+			continue
+		}
+		if label != labels[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
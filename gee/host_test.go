@@ -0,0 +1,136 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEngine_Host(t *testing.T) {
+	e := New()
+	e.Use(func(c *Context) {
+		c.SetHeader("X-Root", "true")
+		c.Next()
+	})
+	e.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "default")
+	})
+
+	admin := e.Host("admin.example.com")
+	admin.Use(func(c *Context) {
+		c.SetHeader("X-Admin", "true")
+		c.Next()
+	})
+	admin.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "admin")
+	})
+
+	tenant := e.Host(":tenant.example.com")
+	tenant.Group("/users").GET("/:id", func(c *Context) {
+		c.String(http.StatusOK, "tenant %s user %s", c.Param("tenant"), c.Param("id"))
+	})
+
+	e.Host(":orgID.Apps.example.com").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "org %s", c.Param("orgID"))
+	})
+
+	tests := []struct {
+		name           string
+		host           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		expectedAdmin  string
+	}{
+		{
+			name:           "exact host",
+			host:           "admin.example.com",
+			path:           "/",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "admin",
+			expectedAdmin:  "true",
+		},
+		{
+			name:           "exact host with port and case",
+			host:           "Admin.Example.com:8080",
+			path:           "/",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "admin",
+			expectedAdmin:  "true",
+		},
+		{
+			name:           "wildcard host captures param",
+			host:           "acme.example.com",
+			path:           "/users/7",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "tenant acme user 7",
+		},
+		{
+			name:           "camelCase param with mixed-case pattern",
+			host:           "Acme.apps.example.com",
+			path:           "/",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "org acme",
+		},
+		{
+			name:           "matched host does not fall back",
+			host:           "acme.example.com",
+			path:           "/",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "404 NOT FOUND: /\n",
+		},
+		{
+			name:           "unknown host falls back to default tree",
+			host:           "www.other.org",
+			path:           "/",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Host = tt.host
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+
+			if rr.Header().Get("X-Root") != "true" {
+				t.Error("Expected root middleware to run for every host")
+			}
+
+			if got := rr.Header().Get("X-Admin"); got != tt.expectedAdmin {
+				t.Errorf("Expected X-Admin %q, got %q", tt.expectedAdmin, got)
+			}
+		})
+	}
+}
+
+func TestEngine_Host_Routes(t *testing.T) {
+	e := New()
+	e.Host("api.example.com").GET("/hello", func(c *Context) {})
+	e.Host("api.example.com").GET("/bye", func(c *Context) {})
+	e.GET("/hello", func(c *Context) {})
+
+	routes := e.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("Expected 3 routes, got %d", len(routes))
+	}
+
+	if routes[0].Host != "api.example.com" || routes[2].Host != "" {
+		t.Errorf("Unexpected hosts %q, %q", routes[0].Host, routes[2].Host)
+	}
+
+	if len(e.hosts) != 1 {
+		t.Errorf("Expected host tree to be shared, got %d trees", len(e.hosts))
+	}
+}
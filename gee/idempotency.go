@@ -0,0 +1,266 @@
+package gee
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultIdempotencyTTL  = 24 * time.Hour
+	defaultIdempotencyLock = time.Minute
+	maxIdempotencyKeyLen   = 255
+)
+
+// IdempotencyRecord is what an IdempotencyStore keeps per key. Done is
+// false while the first request is still running.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Done        bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// ErrIdempotencyClaimLost is returned by Complete when the claim expired
+// and another request took the key over.
+var ErrIdempotencyClaimLost = errors.New("gee: idempotency claim lost")
+
+// IdempotencyStore holds records for Idempotency. Begin must be atomic: of
+// concurrent calls for a new key exactly one claims it.
+type IdempotencyStore interface {
+	// Begin claims key and returns a token for the claim, or returns the
+	// existing record if the key is taken. An unfinished claim expires
+	// after lockTTL so a crashed request does not block the key for good.
+	Begin(key, fingerprint string, lockTTL time.Duration) (token string, rec *IdempotencyRecord, err error)
+	// Complete stores the response of a claimed key for ttl, if token
+	// still holds the claim.
+	Complete(key, token string, rec *IdempotencyRecord, ttl time.Duration) error
+	// Release drops the claim held by token so the request can be retried.
+	// It leaves the key alone once another request has taken it over.
+	Release(key, token string) error
+}
+
+type IdempotencyOptions struct {
+	// Store keeps the records, a private MemoryIdempotencyStore if nil.
+	Store IdempotencyStore
+	// TTL is how long responses are replayed, 24h by default.
+	TTL time.Duration
+	// LockTTL bounds how long a request may hold its key before a
+	// duplicate is allowed to run, 1m by default.
+	LockTTL time.Duration
+	// Scope keeps keys of different callers apart, e.g. by returning the
+	// account id. Keys are global if nil.
+	Scope func(c *Context) string
+	// Required answers 400 to requests without an Idempotency-Key instead
+	// of letting them through.
+	Required bool
+}
+
+// Idempotency makes POST and PATCH requests carrying an Idempotency-Key
+// header safe to retry. The first response for a key is stored with a
+// fingerprint of the request and replayed, marked Idempotent-Replayed,
+// for later duplicates. A duplicate arriving while the first request is
+// still running gets 409, and a key reused for a different request gets
+// 422. 5xx responses are not stored, so the client can retry them.
+func Idempotency(opts IdempotencyOptions) Handler {
+	store := opts.Store
+	if store == nil {
+		store = NewMemoryIdempotencyStore()
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultIdempotencyTTL
+	}
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = defaultIdempotencyLock
+	}
+
+	return func(c *Context) {
+		if c.method != http.MethodPost && c.method != http.MethodPatch {
+			c.Next()
+			return
+		}
+
+		key := c.r.Header.Get("Idempotency-Key")
+		switch {
+		case key == "" && !opts.Required:
+			c.Next()
+			return
+		case key == "":
+			c.Fail(http.StatusBadRequest, "missing Idempotency-Key header")
+			c.Abort()
+			return
+		case len(key) > maxIdempotencyKeyLen:
+			c.Fail(http.StatusBadRequest, "Idempotency-Key too long")
+			c.Abort()
+			return
+		}
+		if opts.Scope != nil {
+			key = opts.Scope(c) + "\n" + key
+		}
+
+		body, err := c.BodyBytes()
+		if err != nil {
+			if errors.Is(err, ErrBodyTooLarge) {
+				c.Fail(http.StatusRequestEntityTooLarge, "request body too large")
+			} else {
+				c.Fail(http.StatusBadRequest, "cannot read request body")
+			}
+			c.Abort()
+			return
+		}
+		c.r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := requestFingerprint(c.r, body)
+
+		token, rec, err := store.Begin(key, fingerprint, opts.LockTTL)
+		if err != nil {
+			c.Fail(http.StatusInternalServerError, "idempotency store unavailable")
+			c.Abort()
+			return
+		}
+		if rec != nil {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				c.Fail(http.StatusUnprocessableEntity, "Idempotency-Key reused for a different request")
+				c.Abort()
+			case !rec.Done:
+				c.SetHeader("Retry-After", "1")
+				c.Fail(http.StatusConflict, "a request with this Idempotency-Key is in progress")
+				c.Abort()
+			default:
+				replayIdempotent(c, rec)
+			}
+			return
+		}
+
+		// released unless completed, which also covers a panicking handler
+		completed := false
+		defer func() {
+			if !completed {
+				store.Release(key, token)
+			}
+		}()
+
+		// headers from outer middleware, like X-Request-ID or a CSRF
+		// cookie, belong to each request and are not replayed
+		before := c.w.Header().Clone()
+		buf := c.bufferResponse()
+		if buf.Status() < http.StatusInternalServerError {
+			completed = store.Complete(key, token, &IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Done:        true,
+				Status:      buf.Status(),
+				Header:      headerChanges(before, c.w.Header()),
+				Body:        bytes.Clone(buf.body.Bytes()),
+			}, opts.TTL) == nil
+		}
+		buf.flushTo(c.w)
+	}
+}
+
+func replayIdempotent(c *Context, rec *IdempotencyRecord) {
+	header := c.w.Header()
+	for k, vs := range rec.Header {
+		header[k] = append([]string(nil), vs...)
+	}
+	header.Set("Idempotent-Replayed", "true")
+	c.w.WriteHeader(rec.Status)
+	c.w.Write(rec.Body)
+	c.Abort()
+}
+
+// requestFingerprint hashes what makes a retry the same request: method,
+// path, query, content type and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Get("Content-Type")} {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore for a single process.
+// Expired records are dropped on access and by a sweep at most once a
+// minute.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]*memoryIdempotencyEntry
+	nextSweep time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	token   string // of the claim that owns the key
+	rec     IdempotencyRecord
+	expires time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]*memoryIdempotencyEntry)}
+}
+
+func (s *MemoryIdempotencyStore) Begin(key, fingerprint string, lockTTL time.Duration) (string, *IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+	if e, ok := s.records[key]; ok && now.Before(e.expires) {
+		rec := e.rec
+		return "", &rec, nil
+	}
+	token := newRequestID()
+	s.records[key] = &memoryIdempotencyEntry{
+		token:   token,
+		rec:     IdempotencyRecord{Fingerprint: fingerprint},
+		expires: now.Add(lockTTL),
+	}
+	return token, nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(key, token string, rec *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.records[key]; !ok || e.token != token || e.rec.Done {
+		return ErrIdempotencyClaimLost
+	}
+	s.records[key] = &memoryIdempotencyEntry{token: token, rec: *rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.records[key]; ok && e.token == token && !e.rec.Done {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.records)
+}
+
+// sweep must be called with s.mu held.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(time.Minute)
+	for key, e := range s.records {
+		if !now.Before(e.expires) {
+			delete(s.records, key)
+		}
+	}
+}
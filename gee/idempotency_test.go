@@ -0,0 +1,273 @@
+package gee
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func idempotentPost(e *Engine, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestIdempotency(t *testing.T) {
+	var charges atomic.Int32
+	e := New()
+	e.Use(Idempotency(IdempotencyOptions{}))
+	e.POST("/charges", func(c *Context) {
+		body, _ := io.ReadAll(c.Request().Body)
+		n := charges.Add(1)
+		c.SetHeader("X-Charge", fmt.Sprint(n))
+		c.String(http.StatusCreated, "charge %d: %s", n, body)
+	})
+
+	tests := []struct {
+		name             string
+		key              string
+		body             string
+		expectedStatus   int
+		expectedBody     string
+		expectedReplayed bool
+		expectedCharges  int32
+	}{
+		{name: "first request", key: "k1", body: "amount=10", expectedStatus: http.StatusCreated, expectedBody: "charge 1: amount=10", expectedCharges: 1},
+		{name: "duplicate is replayed", key: "k1", body: "amount=10", expectedStatus: http.StatusCreated, expectedBody: "charge 1: amount=10", expectedReplayed: true, expectedCharges: 1},
+		{name: "different payload", key: "k1", body: "amount=99", expectedStatus: http.StatusUnprocessableEntity, expectedCharges: 1},
+		{name: "new key", key: "k2", body: "amount=10", expectedStatus: http.StatusCreated, expectedBody: "charge 2: amount=10", expectedCharges: 2},
+		{name: "no key", body: "amount=10", expectedStatus: http.StatusCreated, expectedBody: "charge 3: amount=10", expectedCharges: 3},
+		{name: "key too long", key: strings.Repeat("k", 256), expectedStatus: http.StatusBadRequest, expectedCharges: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := idempotentPost(e, "/charges", tt.key, tt.body)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedBody != "" && rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+			if replayed := rr.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.expectedReplayed {
+				t.Errorf("Expected replayed %v, got %v", tt.expectedReplayed, replayed)
+			}
+			if tt.expectedReplayed && rr.Header().Get("X-Charge") != "1" {
+				t.Errorf("Expected the stored headers to be replayed, got %q", rr.Header().Get("X-Charge"))
+			}
+			if n := charges.Load(); n != tt.expectedCharges {
+				t.Errorf("Expected %d charges, got %d", tt.expectedCharges, n)
+			}
+		})
+	}
+}
+
+func TestIdempotency_ConcurrentDuplicate(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e := New()
+	e.Use(Idempotency(IdempotencyOptions{}))
+	e.POST("/charges", func(c *Context) {
+		close(started)
+		<-release
+		c.String(http.StatusCreated, "ok")
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentPost(e, "/charges", "k", "amount=10") }()
+	<-started
+
+	if rr := idempotentPost(e, "/charges", "k", "amount=10"); rr.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d, got %d", http.StatusConflict, rr.Code)
+	}
+
+	close(release)
+	if rr := <-done; rr.Code != http.StatusCreated {
+		t.Errorf("Expected status code %d, got %d", http.StatusCreated, rr.Code)
+	}
+	if rr := idempotentPost(e, "/charges", "k", "amount=10"); rr.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected the finished response to be replayed")
+	}
+}
+
+func TestIdempotency_ServerErrorsAreRetried(t *testing.T) {
+	var calls atomic.Int32
+	e := New()
+	e.Use(Recovery(), Idempotency(IdempotencyOptions{}))
+	e.POST("/flaky", func(c *Context) {
+		switch calls.Add(1) {
+		case 1:
+			c.Fail(http.StatusServiceUnavailable, "try again")
+		case 2:
+			panic("boom")
+		default:
+			c.String(http.StatusOK, "done")
+		}
+	})
+
+	expected := []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK, http.StatusOK}
+	for i, code := range expected {
+		if rr := idempotentPost(e, "/flaky", "k", ""); rr.Code != code {
+			t.Errorf("Expected attempt %d to get %d, got %d", i+1, code, rr.Code)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Expected 3 handler calls, got %d", n)
+	}
+}
+
+func TestIdempotency_ScopeAndRequired(t *testing.T) {
+	e := New()
+	e.Use(Idempotency(IdempotencyOptions{
+		Required: true,
+		Scope:    func(c *Context) string { return c.GetHeader("X-Account") },
+	}))
+	e.POST("/charges", func(c *Context) {
+		c.String(http.StatusCreated, "%s", c.GetHeader("X-Account"))
+	})
+	e.GET("/charges", func(c *Context) {})
+
+	if rr := idempotentPost(e, "/charges", "", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d without a key, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, httptest.NewRequest("GET", "/charges", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected GET to pass without a key, got %d", rr.Code)
+	}
+
+	for _, account := range []string{"a", "b"} {
+		req := httptest.NewRequest("POST", "/charges", nil)
+		req.Header.Set("Idempotency-Key", "shared")
+		req.Header.Set("X-Account", account)
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+		if rr.Body.String() != account || rr.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("Expected account %s to get its own response, got %q", account, rr.Body.String())
+		}
+	}
+}
+
+func TestMemoryIdempotencyStore_Expiry(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+
+	first, rec, _ := s.Begin("k", "fp", 20*time.Millisecond)
+	if first == "" || rec != nil {
+		t.Fatalf("Expected the first Begin to claim the key, got %+v", rec)
+	}
+	if _, rec, _ := s.Begin("k", "fp", 20*time.Millisecond); rec == nil || rec.Done {
+		t.Errorf("Expected an unfinished record, got %+v", rec)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	second, rec, _ := s.Begin("k", "fp", time.Minute)
+	if second == "" || rec != nil {
+		t.Errorf("Expected an expired claim to be taken over, got %+v", rec)
+	}
+
+	// the first claim no longer owns the key
+	s.Release("k", first)
+	if err := s.Complete("k", first, &IdempotencyRecord{Done: true}, time.Minute); err != ErrIdempotencyClaimLost {
+		t.Errorf("Expected %v, got %v", ErrIdempotencyClaimLost, err)
+	}
+	if _, rec, _ := s.Begin("k", "fp", time.Minute); rec == nil {
+		t.Errorf("Expected the second claim to survive the first one's Release")
+	}
+
+	s.Complete("k", second, &IdempotencyRecord{Fingerprint: "fp", Done: true, Status: 201}, 20*time.Millisecond)
+	if _, rec, _ := s.Begin("k", "fp", time.Minute); rec == nil || rec.Status != 201 {
+		t.Errorf("Expected the stored response, got %+v", rec)
+	}
+
+	other, _, _ := s.Begin("other", "fp", time.Minute)
+	s.Complete("other", other, &IdempotencyRecord{Done: true}, time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	s.nextSweep = time.Time{}
+	s.Begin("new", "fp", time.Minute)
+	if n := s.Len(); n != 1 {
+		t.Errorf("Expected the sweep to leave 1 record, got %d", n)
+	}
+}
+
+func TestIdempotency_ExpiredClaimDoesNotReleaseTakeover(t *testing.T) {
+	var calls atomic.Int32
+	firstDone := make(chan struct{})
+	secondStarted := make(chan struct{})
+	release := make(chan struct{})
+	e := New()
+	e.Use(Idempotency(IdempotencyOptions{LockTTL: 100 * time.Millisecond}))
+	e.POST("/charges", func(c *Context) {
+		switch calls.Add(1) {
+		case 1:
+			<-firstDone
+			c.Fail(http.StatusServiceUnavailable, "try again")
+		case 2:
+			close(secondStarted)
+			<-release
+			c.String(http.StatusCreated, "ok")
+		default:
+			c.String(http.StatusCreated, "duplicate ran")
+		}
+	})
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- idempotentPost(e, "/charges", "k", "amount=10") }()
+	time.Sleep(120 * time.Millisecond)
+
+	second := make(chan *httptest.ResponseRecorder)
+	go func() { second <- idempotentPost(e, "/charges", "k", "amount=10") }()
+	<-secondStarted
+
+	// the first request fails after losing its claim and must not free the
+	// key the second one now holds
+	close(firstDone)
+	if rr := <-first; rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	if rr := idempotentPost(e, "/charges", "k", "amount=10"); rr.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d while the takeover runs, got %d", http.StatusConflict, rr.Code)
+	}
+
+	close(release)
+	if rr := <-second; rr.Code != http.StatusCreated {
+		t.Errorf("Expected status code %d, got %d", http.StatusCreated, rr.Code)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected 2 handler calls, got %d", n)
+	}
+}
+
+func TestIdempotency_KeepsOuterHeaders(t *testing.T) {
+	e := New()
+	e.Use(RequestID(), Idempotency(IdempotencyOptions{}))
+	e.POST("/charges", func(c *Context) {
+		c.SetHeader("Location", "/charges/1")
+		c.Status(http.StatusCreated)
+	})
+
+	ids := make(map[string]bool)
+	for i := range 2 {
+		rr := idempotentPost(e, "/charges", "k1", "amount=10")
+		if replayed := rr.Header().Get("Idempotent-Replayed") == "true"; replayed != (i == 1) {
+			t.Errorf("Expected request %d replayed %v, got %v", i+1, i == 1, replayed)
+		}
+		if rr.Header().Get("Location") != "/charges/1" {
+			t.Errorf("Expected the handler's headers to be replayed, got %v", rr.Header())
+		}
+		ids[rr.Header().Get("X-Request-ID")] = true
+	}
+	if len(ids) != 2 {
+		t.Errorf("Expected each response to keep its own X-Request-ID, got %v", ids)
+	}
+}
@@ -0,0 +1,112 @@
+// Package lru is a byte-bounded LRU cache, the same design as geecache's
+// lru package plus removal so gee can purge entries. It is not safe for
+// concurrent use.
+package lru
+
+import "container/list"
+
+type Cache struct {
+	maxBytes  int64
+	usedBytes int64
+	dl        *list.List
+	cache     map[string]*list.Element
+	OnEvicted func(key string, value Value)
+}
+
+type entry struct {
+	key   string
+	value Value
+}
+
+// Value use Len to count how many bytes it takes
+type Value interface {
+	Len() int
+}
+
+func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		dl:        list.New(),
+		cache:     make(map[string]*list.Element),
+		OnEvicted: onEvicted,
+	}
+}
+
+// Get look ups a key's value
+func (c *Cache) Get(key string) (Value, bool) {
+	ele, exist := c.cache[key]
+	if !exist {
+		return nil, false
+	}
+
+	c.dl.MoveToFront(ele)
+	kv := ele.Value.(*entry)
+	return kv.value, true
+}
+
+// RemoveOldest removes the oldest item
+func (c *Cache) RemoveOldest() {
+	if ele := c.dl.Back(); ele != nil {
+		c.removeElement(ele)
+	}
+}
+
+// Remove removes key, reporting whether it was present.
+func (c *Cache) Remove(key string) bool {
+	ele, ok := c.cache[key]
+	if ok {
+		c.removeElement(ele)
+	}
+	return ok
+}
+
+// RemoveFunc removes every item for which fn returns true and returns how
+// many were removed.
+func (c *Cache) RemoveFunc(fn func(key string, value Value) bool) int {
+	removed := 0
+	for ele := c.dl.Front(); ele != nil; {
+		next := ele.Next()
+		kv := ele.Value.(*entry)
+		if fn(kv.key, kv.value) {
+			c.removeElement(ele)
+			removed++
+		}
+		ele = next
+	}
+	return removed
+}
+
+func (c *Cache) removeElement(ele *list.Element) {
+	c.dl.Remove(ele)
+	kv := ele.Value.(*entry)
+	delete(c.cache, kv.key)
+	c.usedBytes -= int64(len(kv.key)) + int64(kv.value.Len())
+	if c.OnEvicted != nil {
+		c.OnEvicted(kv.key, kv.value)
+	}
+}
+
+// Add adds a value to the cache.
+func (c *Cache) Add(key string, value Value) {
+	if ele, ok := c.cache[key]; ok {
+		c.dl.MoveToFront(ele)
+		kv := ele.Value.(*entry)
+		c.usedBytes += int64(value.Len()) - int64(kv.value.Len())
+		kv.value = value
+	} else {
+		ele := c.dl.PushFront(&entry{key, value})
+		c.cache[key] = ele
+		c.usedBytes += int64(len(key)) + int64(value.Len())
+	}
+	for c.maxBytes < c.usedBytes {
+		c.RemoveOldest()
+	}
+}
+
+func (c *Cache) Len() int {
+	return c.dl.Len()
+}
+
+func (c *Cache) Bytes() int64 {
+	return c.usedBytes
+}
@@ -0,0 +1,61 @@
+package lru
+
+import (
+	"strings"
+	"testing"
+)
+
+type Str string
+
+func (d Str) Len() int {
+	return len(d)
+}
+
+func TestGet(t *testing.T) {
+	lru := New(int64(1000), nil)
+	lru.Add("key1", Str("1234"))
+	if v, ok := lru.Get("key1"); !ok || string(v.(Str)) != "1234" {
+		t.Fatal("lru cache hit key1=1234 failed")
+	}
+	if _, ok := lru.Get("key2"); ok {
+		t.Fatal("cache miss key2 failed")
+	}
+}
+
+func TestRemoveOldest(t *testing.T) {
+	lru := New(int64(len("key1val1key2val2")), nil)
+	lru.Add("key1", Str("val1"))
+	lru.Add("key2", Str("val2"))
+	lru.Add("key3", Str("val3"))
+
+	if lru.Len() != 2 {
+		t.Fatal("lru cache length should be 2")
+	}
+	if _, exist := lru.Get("key1"); exist {
+		t.Fatal("lru cache should not get key1 successfully")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	evicted := make([]string, 0)
+	lru := New(int64(1000), func(key string, value Value) {
+		evicted = append(evicted, key)
+	})
+	lru.Add("/a/1", Str("v"))
+	lru.Add("/a/2", Str("v"))
+	lru.Add("/b/1", Str("v"))
+
+	if !lru.Remove("/b/1") || lru.Remove("/b/1") {
+		t.Fatal("Remove should report presence once")
+	}
+
+	removed := lru.RemoveFunc(func(key string, value Value) bool {
+		return strings.HasPrefix(key, "/a/")
+	})
+	if removed != 2 || lru.Len() != 0 || lru.Bytes() != 0 {
+		t.Fatalf("RemoveFunc removed %d, left %d items and %d bytes", removed, lru.Len(), lru.Bytes())
+	}
+	if len(evicted) != 3 {
+		t.Fatalf("OnEvicted called for %v", evicted)
+	}
+}
@@ -0,0 +1,47 @@
+// Package singleflight collapses concurrent calls for the same key into
+// one, as geecache's singleflight package does.
+package singleflight
+
+import (
+	"sync"
+)
+
+type call struct {
+	wg  sync.WaitGroup
+	res any
+	err error
+}
+
+type Group struct {
+	mu sync.Mutex
+	m  map[string]*call
+}
+
+// Do runs fn once for all concurrent callers of key. The waiters are
+// released even if fn panics, in which case they get a nil result.
+func (g *Group) Do(key string, fn func() (any, error)) (any, error) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.res, c.err
+	}
+
+	c := new(call)
+	g.m[key] = c
+	c.wg.Add(1)
+	g.mu.Unlock()
+
+	defer func() {
+		c.wg.Done()
+		g.mu.Lock()
+		delete(g.m, key)
+		g.mu.Unlock()
+	}()
+
+	c.res, c.err = fn()
+	return c.res, c.err
+}
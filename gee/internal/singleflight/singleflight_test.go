@@ -0,0 +1,68 @@
+package singleflight
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoDupSuppress(t *testing.T) {
+	var g Group
+	c := make(chan string)
+	var calls int32
+	fn := func() (any, error) {
+		atomic.AddInt32(&calls, 1)
+		return <-c, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			v, err := g.Do("key", fn)
+			if err != nil || v.(string) != "bar" {
+				t.Errorf("got %v, %v, want bar", v, err)
+			}
+		})
+	}
+	time.Sleep(100 * time.Millisecond) // 让所有 goroutine 都进入 Do
+	c <- "bar"
+	wg.Wait()
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("number of calls = %d, want 1", got)
+	}
+}
+
+func TestDoPanicReleasesWaiters(t *testing.T) {
+	var g Group
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() { recover() }()
+		g.Do("key", func() (any, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+
+	<-started
+	done := make(chan any)
+	go func() {
+		v, _ := g.Do("key", func() (any, error) { return "second", nil })
+		done <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	select {
+	case v := <-done:
+		if v != nil && v != "second" {
+			t.Errorf("got %v", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released after panic")
+	}
+}
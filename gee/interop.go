@@ -0,0 +1,82 @@
+package gee
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WrapH runs a net/http Handler as a gee Handler.
+func WrapH(h http.Handler) Handler {
+	return func(c *Context) {
+		h.ServeHTTP(c.w, c.r)
+	}
+}
+
+// WrapF runs a net/http handler function as a gee Handler.
+func WrapF(f http.HandlerFunc) Handler {
+	return WrapH(f)
+}
+
+type contextKey struct{}
+
+// FromStd adapts func(http.Handler) http.Handler style middleware. The
+// rest of the gee chain runs as the wrapped handler and sees whatever
+// ResponseWriter and Request the middleware passes on; if the middleware
+// never calls it, the chain is aborted.
+func FromStd(mw func(http.Handler) http.Handler) Handler {
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context().Value(contextKey{}).(*Context)
+		w0, r0 := c.w, c.r
+		c.w, c.r = w, r
+		c.Next()
+		c.w, c.r = w0, r0
+	}))
+
+	return func(c *Context) {
+		index := c.index
+		h.ServeHTTP(c.w, c.r.WithContext(context.WithValue(c.r.Context(), contextKey{}, c)))
+		if c.index == index {
+			c.Abort()
+		}
+	}
+}
+
+var mountMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+func (g *RouteGroup) Handle(method, pattern string, handlers ...Handler) *Route {
+	return g.addRoute(method, pattern, handlers...)
+}
+
+// Mount serves every request under prefix with h, stripping the group
+// prefix and prefix from the path first. The group's middleware runs in
+// front of h, so a mounted *Engine keeps its parent's middleware.
+func (g *RouteGroup) Mount(prefix string, h http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	full := g.prefix + prefix
+
+	handler := func(c *Context) {
+		r2 := new(http.Request)
+		*r2 = *c.r
+		r2.URL = new(url.URL)
+		*r2.URL = *c.r.URL
+		r2.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(c.r.URL.Path, full), "/")
+		if c.r.URL.RawPath != "" {
+			r2.URL.RawPath = "/" + strings.TrimLeft(strings.TrimPrefix(c.r.URL.RawPath, full), "/")
+		}
+		h.ServeHTTP(c.w, r2)
+	}
+
+	root := prefix
+	if root == "" {
+		root = "/"
+	}
+	for _, method := range mountMethods {
+		g.addRoute(method, root, handler)
+		g.addRoute(method, prefix+"/*", handler)
+	}
+}
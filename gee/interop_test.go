@@ -0,0 +1,154 @@
+package gee
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapH(t *testing.T) {
+	e := New()
+	e.GET("/std", WrapH(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("std " + r.URL.Path))
+	})))
+	e.GET("/stdf", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stdf"))
+	}))
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{path: "/std", expectedStatus: http.StatusAccepted, expectedBody: "std /std"},
+		{path: "/stdf", expectedStatus: http.StatusOK, expectedBody: "stdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+type ctxValueKey struct{}
+
+func TestFromStd(t *testing.T) {
+	tests := []struct {
+		name           string
+		mw             func(http.Handler) http.Handler
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "passes request changes down the chain",
+			mw: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Std", "true")
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxValueKey{}, "from-std")))
+				})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "from-std",
+		},
+		{
+			name: "not calling next aborts",
+			mw: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Std", "true")
+					http.Error(w, "denied", http.StatusForbidden)
+				})
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "denied\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.Use(FromStd(tt.mw))
+			e.GET("/test", func(c *Context) {
+				v, _ := c.Request().Context().Value(ctxValueKey{}).(string)
+				c.String(http.StatusOK, "%s", v)
+			})
+
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, httptest.NewRequest("GET", "/test", nil))
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+			if rr.Header().Get("X-Std") != "true" {
+				t.Error("Expected std middleware header")
+			}
+		})
+	}
+}
+
+func TestRouteGroup_Mount(t *testing.T) {
+	child := New()
+	child.Use(func(c *Context) {
+		c.SetHeader("X-Child", "true")
+		c.Next()
+	})
+	child.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "child root")
+	})
+	child.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "child user %s at %s", c.Param("id"), c.Path())
+	})
+
+	parent := New()
+	parent.Use(func(c *Context) {
+		c.SetHeader("X-Parent", "true")
+		c.Next()
+	})
+	parent.Group("/api").Mount("/v1", child)
+	parent.Mount("/static", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("static " + r.URL.Path))
+	}))
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		expectedBody  string
+		expectedChild bool
+	}{
+		{name: "mounted engine", method: "GET", path: "/api/v1/users/7", expectedBody: "child user 7 at /users/7", expectedChild: true},
+		{name: "mounted engine root", method: "GET", path: "/api/v1", expectedBody: "child root", expectedChild: true},
+		{name: "mounted engine 404", method: "POST", path: "/api/v1/users/7", expectedBody: "404 NOT FOUND: /users/7\n", expectedChild: true},
+		{name: "plain handler", method: "GET", path: "/static/css/a.css", expectedBody: "static /css/a.css"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			parent.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+			if rr.Header().Get("X-Parent") != "true" {
+				t.Error("Expected parent middleware to run")
+			}
+			if got := rr.Header().Get("X-Child") == "true"; got != tt.expectedChild {
+				t.Errorf("Expected child middleware %v, got %v", tt.expectedChild, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package gee
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Error codes defined by the JSON-RPC 2.0 spec.
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+)
+
+// RPCError is a JSON-RPC error object. Methods return one to pick the code
+// the client sees, any other error is reported as an internal error.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("jsonrpc: %d %s", e.Code, e.Message)
+}
+
+type rpcFunc func(c *Context, params json.RawMessage) (any, error)
+
+type RPCRegistry struct {
+	mu      sync.RWMutex
+	methods map[string]rpcFunc
+}
+
+func NewRPCRegistry() *RPCRegistry {
+	return &RPCRegistry{methods: make(map[string]rpcFunc)}
+}
+
+// RegisterRPC adds fn to reg under name. Params are decoded into P, by
+// name from an object or, when P is a struct, by field order from an
+// array; absent params leave P zero.
+//
+//	gee.RegisterRPC(reg, "math.add", func(c *gee.Context, p AddParams) (int, error) {
+//		return p.A + p.B, nil
+//	})
+func RegisterRPC[P, R any](reg *RPCRegistry, name string, fn func(c *Context, params P) (R, error)) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.methods[name] = func(c *Context, raw json.RawMessage) (any, error) {
+		var params P
+		if err := decodeRPCParams(raw, &params); err != nil {
+			return nil, &RPCError{Code: RPCInvalidParams, Message: "Invalid params", Data: err.Error()}
+		}
+		return fn(c, params)
+	}
+}
+
+func (reg *RPCRegistry) lookup(name string) (rpcFunc, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	fn, ok := reg.methods[name]
+	return fn, ok
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	// ID stays nil when the member is absent, which makes a notification.
+	ID json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// JSONRPC serves JSON-RPC 2.0 calls, single or batched, on a POST route:
+//
+//	r.POST("/rpc", gee.JSONRPC(reg))
+//
+// Notifications are run but never answered; a request or batch of only
+// notifications gets 204.
+func JSONRPC(reg *RPCRegistry) Handler {
+	return func(c *Context) {
+		body, err := c.BodyBytes()
+		if errors.Is(err, ErrBodyTooLarge) {
+			c.Fail(http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusOK, rpcErrorResponse(nil, RPCParseError, "Parse error"))
+			return
+		}
+
+		body = bytes.TrimSpace(body)
+		if len(body) == 0 || body[0] != '[' {
+			if resp, ok := reg.call(c, body); ok {
+				c.JSON(http.StatusOK, resp)
+				return
+			}
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			c.JSON(http.StatusOK, rpcErrorResponse(nil, RPCParseError, "Parse error"))
+			return
+		}
+		if len(batch) == 0 {
+			c.JSON(http.StatusOK, rpcErrorResponse(nil, RPCInvalidRequest, "Invalid Request"))
+			return
+		}
+
+		responses := make([]*rpcResponse, 0, len(batch))
+		for _, raw := range batch {
+			if resp, ok := reg.call(c, raw); ok {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+// call runs one request; ok is false for a notification.
+func (reg *RPCRegistry) call(c *Context, raw json.RawMessage) (*rpcResponse, bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || len(raw) == 0 {
+			return rpcErrorResponse(nil, RPCParseError, "Parse error"), true
+		}
+		return rpcErrorResponse(nil, RPCInvalidRequest, "Invalid Request"), true
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" || !validRPCID(req.ID) || !validRPCParams(req.Params) {
+		return rpcErrorResponse(nil, RPCInvalidRequest, "Invalid Request"), true
+	}
+
+	result, err := reg.invoke(c, req)
+	if req.ID == nil {
+		return nil, false
+	}
+	if err != nil {
+		return &rpcResponse{JSONRPC: "2.0", Error: toRPCError(err), ID: req.ID}, true
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return &rpcResponse{JSONRPC: "2.0", Error: toRPCError(err), ID: req.ID}, true
+	}
+	return &rpcResponse{JSONRPC: "2.0", Result: encoded, ID: req.ID}, true
+}
+
+// invoke turns a panic into an internal error so one bad call doesn't
+// take the rest of a batch down with it.
+func (reg *RPCRegistry) invoke(c *Context, req rpcRequest) (result any, err error) {
+	fn, ok := reg.lookup(req.Method)
+	if !ok {
+		return nil, &RPCError{Code: RPCMethodNotFound, Message: "Method not found"}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(c, req.Params)
+}
+
+// toRPCError hides the text of plain errors outside debug mode, it may
+// contain internals the client shouldn't see.
+func toRPCError(err error) *RPCError {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	internal := &RPCError{Code: RPCInternalError, Message: "Internal error"}
+	if IsDebugging() {
+		internal.Data = err.Error()
+	}
+	return internal
+}
+
+func rpcErrorResponse(id json.RawMessage, code int, message string) *rpcResponse {
+	return &rpcResponse{JSONRPC: "2.0", Error: &RPCError{Code: code, Message: message}, ID: id}
+}
+
+func validRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+func validRPCParams(params json.RawMessage) bool {
+	return params == nil || params[0] == '{' || params[0] == '['
+}
+
+func decodeRPCParams(raw json.RawMessage, params any) error {
+	if raw == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(params).Elem()
+	if raw[0] != '[' || v.Kind() != reflect.Struct {
+		return json.Unmarshal(raw, params)
+	}
+
+	var positional []json.RawMessage
+	if err := json.Unmarshal(raw, &positional); err != nil {
+		return err
+	}
+	fields := make([]reflect.Value, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() {
+			fields = append(fields, v.Field(i))
+		}
+	}
+	if len(positional) > len(fields) {
+		return fmt.Errorf("got %d params, want at most %d", len(positional), len(fields))
+	}
+	for i, p := range positional {
+		if err := json.Unmarshal(p, fields[i].Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
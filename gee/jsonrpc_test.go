@@ -0,0 +1,185 @@
+package gee
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type subtractParams struct {
+	Minuend    int `json:"minuend"`
+	Subtrahend int `json:"subtrahend"`
+}
+
+func newRPCEngine(notified *atomic.Int32) *Engine {
+	reg := NewRPCRegistry()
+	RegisterRPC(reg, "subtract", func(c *Context, p subtractParams) (int, error) {
+		return p.Minuend - p.Subtrahend, nil
+	})
+	RegisterRPC(reg, "sum", func(c *Context, p []int) (int, error) {
+		total := 0
+		for _, n := range p {
+			total += n
+		}
+		return total, nil
+	})
+	RegisterRPC(reg, "notify", func(c *Context, p []any) (any, error) {
+		notified.Add(1)
+		return nil, nil
+	})
+	RegisterRPC(reg, "divide", func(c *Context, p []float64) (float64, error) {
+		if p[1] == 0 {
+			return 0, &RPCError{Code: 1001, Message: "division by zero"}
+		}
+		return p[0] / p[1], nil
+	})
+	RegisterRPC(reg, "fail", func(c *Context, p any) (any, error) {
+		return nil, errors.New("database password is hunter2")
+	})
+	RegisterRPC(reg, "panic", func(c *Context, p any) (any, error) {
+		panic("boom")
+	})
+
+	e := New()
+	e.POST("/rpc", JSONRPC(reg))
+	return e
+}
+
+func TestJSONRPC(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		expectedNotify int32
+	}{
+		{
+			name:           "positional params into a struct",
+			body:           `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","result":19,"id":1}`,
+		},
+		{
+			name:           "named params",
+			body:           `{"jsonrpc":"2.0","method":"subtract","params":{"subtrahend":23,"minuend":42},"id":"a"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","result":19,"id":"a"}`,
+		},
+		{
+			name:           "null result is still sent",
+			body:           `{"jsonrpc":"2.0","method":"notify","params":[],"id":2}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","result":null,"id":2}`,
+			expectedNotify: 1,
+		},
+		{
+			name:           "notification",
+			body:           `{"jsonrpc":"2.0","method":"notify","params":[1,2]}`,
+			expectedStatus: http.StatusNoContent,
+			expectedNotify: 1,
+		},
+		{
+			name:           "unknown method",
+			body:           `{"jsonrpc":"2.0","method":"foobar","id":"1"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":"1"}`,
+		},
+		{
+			name:           "invalid JSON",
+			body:           `{"jsonrpc":"2.0","method":"foobar,"params":"bar","baz]`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		},
+		{
+			name:           "invalid request object",
+			body:           `{"jsonrpc":"2.0","method":1,"params":"bar"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`,
+		},
+		{
+			name:           "wrong version",
+			body:           `{"jsonrpc":"1.0","method":"sum","id":1}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`,
+		},
+		{
+			name:           "invalid params",
+			body:           `{"jsonrpc":"2.0","method":"subtract","params":{"minuend":"x"},"id":3}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"code":-32602`,
+		},
+		{
+			name:           "application error code",
+			body:           `{"jsonrpc":"2.0","method":"divide","params":[1,0],"id":4}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","error":{"code":1001,"message":"division by zero"},"id":4}`,
+		},
+		{
+			name:           "plain errors are internal and hidden",
+			body:           `{"jsonrpc":"2.0","method":"fail","id":5}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":5}`,
+		},
+		{
+			name:           "empty batch",
+			body:           `[]`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`,
+		},
+		{
+			name:           "invalid batch entries",
+			body:           `[1,2]`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}]`,
+		},
+		{
+			name: "mixed batch",
+			body: `[
+				{"jsonrpc":"2.0","method":"sum","params":[1,2,4],"id":"1"},
+				{"jsonrpc":"2.0","method":"notify","params":[7]},
+				{"jsonrpc":"2.0","method":"panic","id":"2"},
+				{"foo":"boo"},
+				{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":"9"}
+			]`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"jsonrpc":"2.0","result":7,"id":"1"},` +
+				`{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":"2"},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null},` +
+				`{"jsonrpc":"2.0","result":19,"id":"9"}]`,
+			expectedNotify: 1,
+		},
+		{
+			name:           "batch of notifications",
+			body:           `[{"jsonrpc":"2.0","method":"notify","params":[1]},{"jsonrpc":"2.0","method":"notify"}]`,
+			expectedStatus: http.StatusNoContent,
+			expectedNotify: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var notified atomic.Int32
+			e := newRPCEngine(&notified)
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, httptest.NewRequest("POST", "/rpc", strings.NewReader(tt.body)))
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			body := strings.TrimSpace(rr.Body.String())
+			if tt.expectedBody == "" && body != "" {
+				t.Errorf("Expected no body, got %s", body)
+			}
+			if !strings.Contains(body, tt.expectedBody) {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, body)
+			}
+			if n := notified.Load(); n != tt.expectedNotify {
+				t.Errorf("Expected %d notifications, got %d", tt.expectedNotify, n)
+			}
+		})
+	}
+}
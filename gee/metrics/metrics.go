@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/loveRyujin/gee"
+)
+
+const unmatchedRoute = "unmatched"
+
+type Config struct {
+	// Registry receives the request metrics, a new one is created if nil.
+	Registry *Registry
+	// Namespace prefixes the metric names, "gee" by default.
+	Namespace string
+	// Path is where Use exposes the registry, "/metrics" by default.
+	Path string
+	// Buckets of the latency histogram in seconds, DefBuckets if nil.
+	Buckets []float64
+}
+
+type Metrics struct {
+	registry *Registry
+	path     string
+	requests *CounterVec
+	inFlight *GaugeVec
+	duration *HistogramVec
+}
+
+func New(cfg Config) *Metrics {
+	if cfg.Registry == nil {
+		cfg.Registry = NewRegistry()
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = "gee"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/metrics"
+	}
+
+	prefix := cfg.Namespace + "_http_"
+	return &Metrics{
+		registry: cfg.Registry,
+		path:     cfg.Path,
+		requests: cfg.Registry.NewCounterVec(prefix+"requests_total", "Total number of HTTP requests.", "method", "route", "status"),
+		inFlight: cfg.Registry.NewGaugeVec(prefix+"requests_in_flight", "Number of HTTP requests being served.", "method", "route"),
+		duration: cfg.Registry.NewHistogramVec(prefix+"request_duration_seconds", "HTTP request latency in seconds.", cfg.Buckets, "method", "route", "status"),
+	}
+}
+
+// Use installs the middleware on e and exposes the registry at cfg.Path.
+func Use(e *gee.Engine, cfg Config) *Metrics {
+	m := New(cfg)
+	e.Use(m.Middleware())
+	e.GET(m.path, m.Handler())
+	return m
+}
+
+func (m *Metrics) Registry() *Registry {
+	return m.registry
+}
+
+// Middleware records every request, labeled by the route pattern rather
+// than the raw path so the label cardinality stays bounded.
+func (m *Metrics) Middleware() gee.Handler {
+	return func(c *gee.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		start := time.Now()
+		inFlight := m.inFlight.WithLabelValues(c.Method(), route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		c.Next()
+
+		status := c.StatusCode()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		code := strconv.Itoa(status)
+		m.requests.WithLabelValues(c.Method(), route, code).Inc()
+		m.duration.WithLabelValues(c.Method(), route, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the registry in the text exposition format.
+func (m *Metrics) Handler() gee.Handler {
+	return func(c *gee.Context) {
+		var buf bytes.Buffer
+		m.registry.WriteTo(&buf)
+		c.SetHeader("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Data(http.StatusOK, buf.Bytes())
+	}
+}
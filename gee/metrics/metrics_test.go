@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/loveRyujin/gee"
+	"github.com/loveRyujin/gee/geetest"
+)
+
+func TestMain(m *testing.M) {
+	gee.SetMode(gee.TestMode)
+	m.Run()
+}
+
+func TestRegistry_WriteTo(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounter("jobs_total", "Jobs done.").Add(3)
+	g := reg.NewGaugeVec("queue_depth", "Queue depth\nper queue.", "queue")
+	g.WithLabelValues(`a"b`).Set(2)
+	g.WithLabelValues("x").Dec()
+	h := reg.NewHistogram("job_seconds", "Job latency.", []float64{1, 0.1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	var sb strings.Builder
+	if _, err := reg.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP job_seconds Job latency.
+# TYPE job_seconds histogram
+job_seconds_bucket{le="0.1"} 1
+job_seconds_bucket{le="1"} 2
+job_seconds_bucket{le="+Inf"} 3
+job_seconds_sum 5.55
+job_seconds_count 3
+# HELP jobs_total Jobs done.
+# TYPE jobs_total counter
+jobs_total 3
+# HELP queue_depth Queue depth\nper queue.
+# TYPE queue_depth gauge
+queue_depth{queue="a\"b"} 2
+queue_depth{queue="x"} -1
+`
+	if sb.String() != expected {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", sb.String(), expected)
+	}
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(reg *Registry)
+	}{
+		{
+			name: "duplicate name",
+			fn: func(reg *Registry) {
+				reg.NewCounter("dup", "")
+				reg.NewGauge("dup", "")
+			},
+		},
+		{
+			name: "wrong label count",
+			fn: func(reg *Registry) {
+				reg.NewCounterVec("c", "", "a", "b").WithLabelValues("x")
+			},
+		},
+		{
+			name: "counter decrease",
+			fn: func(reg *Registry) {
+				reg.NewCounter("c", "").Add(-1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected panic")
+				}
+			}()
+			tt.fn(NewRegistry())
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	e := gee.New()
+	m := Use(e, Config{Path: "/internal/metrics"})
+	e.GET("/hello/:name", func(c *gee.Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	e.POST("/fail", func(c *gee.Context) {
+		c.Fail(http.StatusBadRequest, "bad")
+	})
+	jobs := m.Registry().NewCounter("app_jobs_total", "Custom jobs.")
+	jobs.Inc()
+
+	client := geetest.New(e)
+	client.GET("/hello/a").Do(t).Status(http.StatusOK)
+	client.GET("/hello/b").Do(t).Status(http.StatusOK)
+	client.POST("/fail").Do(t).Status(http.StatusBadRequest)
+	client.GET("/nope").Do(t).Status(http.StatusNotFound)
+
+	resp := client.GET("/internal/metrics").Do(t).Status(http.StatusOK)
+	for _, line := range []string{
+		`gee_http_requests_total{method="GET",route="/hello/:name",status="200"} 2`,
+		`gee_http_requests_total{method="POST",route="/fail",status="400"} 1`,
+		`gee_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`gee_http_request_duration_seconds_count{method="GET",route="/hello/:name",status="200"} 2`,
+		`gee_http_requests_in_flight{method="GET",route="/internal/metrics"} 1`,
+		`app_jobs_total 1`,
+	} {
+		resp.BodyContains(line + "\n")
+	}
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+)
+
+type Counter struct {
+	value atomicFloat
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increases the counter, negative deltas panic.
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.value.Add(delta)
+}
+
+func (c *Counter) Value() float64 {
+	return c.value.Load()
+}
+
+type CounterVec struct {
+	*vec[Counter]
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{newVec(metric{name: name, help: help, kind: "counter", labels: labels}, func() *Counter { return &Counter{} })}
+	r.register(v.metric, v)
+	return v
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	return v.with(values)
+}
+
+func (v *CounterVec) write(w io.Writer, name string) {
+	v.writeHeader(w)
+	for _, c := range v.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", name, labelString(v.labels, c.values), formatFloat(c.value.Value()))
+	}
+}
+
+type Gauge struct {
+	value atomicFloat
+}
+
+func (g *Gauge) Set(value float64) { g.value.Set(value) }
+func (g *Gauge) Add(delta float64) { g.value.Add(delta) }
+func (g *Gauge) Sub(delta float64) { g.value.Add(-delta) }
+func (g *Gauge) Inc()              { g.value.Add(1) }
+func (g *Gauge) Dec()              { g.value.Add(-1) }
+func (g *Gauge) Value() float64    { return g.value.Load() }
+
+type GaugeVec struct {
+	*vec[Gauge]
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{newVec(metric{name: name, help: help, kind: "gauge", labels: labels}, func() *Gauge { return &Gauge{} })}
+	r.register(v.metric, v)
+	return v
+}
+
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).WithLabelValues()
+}
+
+func (v *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	return v.with(values)
+}
+
+func (v *GaugeVec) write(w io.Writer, name string) {
+	v.writeHeader(w)
+	for _, c := range v.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", name, labelString(v.labels, c.values), formatFloat(c.value.Value()))
+	}
+}
+
+// DefBuckets are latency buckets in seconds, from 5ms to 10s.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type Histogram struct {
+	upperBounds []float64
+	counts      []atomic.Uint64
+	count       atomic.Uint64
+	sum         atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{upperBounds: buckets, counts: make([]atomic.Uint64, len(buckets))}
+}
+
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.upperBounds, value)
+	if i < len(h.counts) {
+		h.counts[i].Add(1)
+	}
+	h.count.Add(1)
+	h.sum.Add(value)
+}
+
+type HistogramVec struct {
+	*vec[Histogram]
+}
+
+// NewHistogramVec registers a histogram, nil buckets means DefBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	v := &HistogramVec{newVec(metric{name: name, help: help, kind: "histogram", labels: labels}, func() *Histogram { return newHistogram(buckets) })}
+	r.register(v.metric, v)
+	return v
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return v.with(values)
+}
+
+func (v *HistogramVec) write(w io.Writer, name string) {
+	v.writeHeader(w)
+	for _, c := range v.sorted() {
+		h := c.value
+		var cumulative uint64
+		for i, bound := range h.upperBounds {
+			cumulative += h.counts[i].Load()
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, labelString(v.labels, c.values, "le", formatFloat(bound)), cumulative)
+		}
+		count := h.count.Load()
+		if count < cumulative {
+			count = cumulative
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, labelString(v.labels, c.values, "le", "+Inf"), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, labelString(v.labels, c.values), formatFloat(h.sum.Load()))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, labelString(v.labels, c.values), count)
+	}
+}
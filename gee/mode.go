@@ -0,0 +1,53 @@
+package gee
+
+import (
+	"log"
+	"os"
+	"sync/atomic"
+)
+
+const EnvGeeMode = "GEE_MODE"
+
+const (
+	DebugMode   = "debug"
+	ReleaseMode = "release"
+	TestMode    = "test"
+)
+
+var geeMode atomic.Value
+
+func init() {
+	SetMode(os.Getenv(EnvGeeMode))
+}
+
+// SetMode switches between debug, release and test. Only debug mode logs
+// route registration and debug warnings; an empty value means debug.
+func SetMode(mode string) {
+	switch mode {
+	case "":
+		mode = DebugMode
+	case DebugMode, ReleaseMode, TestMode:
+	default:
+		panic("gee: unknown mode " + mode)
+	}
+
+	geeMode.Store(mode)
+}
+
+func Mode() string {
+	return geeMode.Load().(string)
+}
+
+func IsDebugging() bool {
+	return Mode() == DebugMode
+}
+
+func debugPrintf(format string, args ...any) {
+	if IsDebugging() {
+		log.Printf(format, args...)
+	}
+}
+
+func debugWarning(format string, args ...any) {
+	debugPrintf("[WARNING] "+format, args...)
+}
@@ -0,0 +1,77 @@
+package gee
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	SetMode(TestMode)
+	os.Exit(m.Run())
+}
+
+func TestSetMode(t *testing.T) {
+	defer SetMode(TestMode)
+
+	tests := []struct {
+		name         string
+		mode         string
+		expectedMode string
+		expectedLog  bool
+	}{
+		{
+			name:         "empty means debug",
+			mode:         "",
+			expectedMode: DebugMode,
+			expectedLog:  true,
+		},
+		{
+			name:         "debug",
+			mode:         DebugMode,
+			expectedMode: DebugMode,
+			expectedLog:  true,
+		},
+		{
+			name:         "release",
+			mode:         ReleaseMode,
+			expectedMode: ReleaseMode,
+			expectedLog:  false,
+		},
+		{
+			name:         "test",
+			mode:         TestMode,
+			expectedMode: TestMode,
+			expectedLog:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			SetMode(tt.mode)
+			if Mode() != tt.expectedMode {
+				t.Errorf("Expected mode %q, got %q", tt.expectedMode, Mode())
+			}
+
+			New().GET("/hello", func(c *Context) {})
+			if logged := buf.Len() > 0; logged != tt.expectedLog {
+				t.Errorf("Expected route logging %v, got %q", tt.expectedLog, buf.String())
+			}
+		})
+	}
+}
+
+func TestSetMode_Unknown(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on unknown mode")
+		}
+	}()
+
+	SetMode("verbose")
+}
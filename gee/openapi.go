@@ -0,0 +1,363 @@
+package gee
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RouteDoc is the optional OpenAPI metadata attached to a route. Request and
+// the values of Responses are sample values (usually zero structs) whose
+// types the schemas are derived from; a nil response means no body.
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	Request     any
+	Responses   map[int]any
+	// Hidden keeps the route out of the document and the documentation check.
+	Hidden bool
+}
+
+func (rt *Route) Doc(doc RouteDoc) *Route {
+	rt.engine.mu.Lock()
+	defer rt.engine.mu.Unlock()
+
+	rt.doc = &doc
+	return rt
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+}
+
+// OpenAPI builds an OpenAPI 3.1 document from the routes registered so far.
+// Paths carry no host, so when Engine.Host trees register the same method
+// and pattern only the first route is documented.
+func (e *Engine) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	gen := &schemaGenerator{
+		schemas: make(map[string]*OpenAPISchema),
+		names:   make(map[reflect.Type]string),
+	}
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+
+	for _, rt := range e.routes {
+		if rt.doc != nil && rt.doc.Hidden {
+			continue
+		}
+
+		path, params := openAPIPath(rt.pattern)
+		method := strings.ToLower(rt.method)
+		if _, ok := doc.Paths[path][method]; ok {
+			host := ""
+			if rt.host != nil {
+				host = rt.host.pattern
+			}
+			debugWarning("OpenAPI leaves out %s %s of host %q, the same operation is already documented", rt.method, rt.pattern, host)
+			continue
+		}
+		op := &OpenAPIOperation{
+			OperationID: rt.name,
+			Parameters:  params,
+			Responses:   make(map[string]*OpenAPIResponse),
+		}
+		if rt.doc != nil {
+			op.Summary = rt.doc.Summary
+			op.Description = rt.doc.Description
+			op.Tags = rt.doc.Tags
+			op.Deprecated = rt.doc.Deprecated
+			if rt.doc.Request != nil {
+				op.RequestBody = &OpenAPIRequestBody{
+					Required: true,
+					Content:  jsonContent(gen.schemaOf(reflect.TypeOf(rt.doc.Request))),
+				}
+			}
+			for status, body := range rt.doc.Responses {
+				resp := &OpenAPIResponse{Description: http.StatusText(status)}
+				if body != nil {
+					resp.Content = jsonContent(gen.schemaOf(reflect.TypeOf(body)))
+				}
+				op.Responses[strconv.Itoa(status)] = resp
+			}
+		}
+		if len(op.Responses) == 0 {
+			op.Responses["default"] = &OpenAPIResponse{Description: "default response"}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		doc.Paths[path][method] = op
+	}
+
+	if len(gen.schemas) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: gen.schemas}
+	}
+
+	return doc
+}
+
+// ServeOpenAPI registers a GET route at path that serves the document. The
+// document is rebuilt per request so routes added later are included.
+func (g *RouteGroup) ServeOpenAPI(path string, info OpenAPIInfo) *Route {
+	e := g.engine
+	return g.GET(path, func(c *Context) {
+		c.JSON(http.StatusOK, e.OpenAPI(info))
+	}).Doc(RouteDoc{Hidden: true})
+}
+
+// CheckDocumented reports every route registered without a RouteDoc, so a
+// test can fail as soon as someone forgets to document an endpoint:
+//
+//	if err := e.CheckDocumented(); err != nil {
+//		t.Fatal(err)
+//	}
+func (e *Engine) CheckDocumented() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	missing := make([]string, 0)
+	for _, rt := range e.routes {
+		if rt.doc == nil {
+			missing = append(missing, rt.method+" "+rt.pattern)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("gee: undocumented routes: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// openAPIPath turns /users/:id/*filepath into /users/{id}/{filepath}, and
+// {id:int} style constraints into typed parameter schemas. An unnamed *
+// becomes {path}.
+func openAPIPath(pattern string) (string, []*OpenAPIParameter) {
+	params := make([]*OpenAPIParameter, 0)
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if part != "*" && (len(part) < 2 || (!isParamPart(part) && part[0] != '*')) {
+			continue
+		}
+
+		name := "path"
+		if part != "*" {
+			name = paramName(part)
+		}
+		param := &OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &OpenAPISchema{Type: "string"},
+		}
+		switch part[0] {
+		case '*':
+			param.Description = "matches the rest of the path, slashes included"
+		case '{':
+			_, expr, _ := strings.Cut(part[1:len(part)-1], ":")
+			param.Schema = constraintSchema(expr)
+		}
+		params = append(params, param)
+		parts[i] = "{" + name + "}"
+	}
+
+	return strings.Join(parts, "/"), params
+}
+
+func constraintSchema(expr string) *OpenAPISchema {
+	switch expr {
+	case "":
+		return &OpenAPISchema{Type: "string"}
+	case "int":
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case "uint":
+		zero := 0.0
+		return &OpenAPISchema{Type: "integer", Minimum: &zero}
+	case "uuid":
+		return &OpenAPISchema{Type: "string", Format: "uuid"}
+	}
+	if typed, ok := paramTypes[expr]; ok {
+		expr = typed
+	}
+	return &OpenAPISchema{Type: "string", Pattern: "^(?:" + expr + ")$"}
+}
+
+func jsonContent(schema *OpenAPISchema) map[string]*OpenAPIMediaType {
+	return map[string]*OpenAPIMediaType{"application/json": {Schema: schema}}
+}
+
+type schemaGenerator struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) *OpenAPISchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &OpenAPISchema{Type: "string", Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &OpenAPISchema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + g.register(t)}
+	}
+
+	return &OpenAPISchema{}
+}
+
+// register adds a named struct to components once, which also keeps
+// recursive types from looping forever.
+func (g *schemaGenerator) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	for i := 2; g.schemas[name] != nil; i++ {
+		name = t.Name() + strconv.Itoa(i)
+	}
+	g.names[t] = name
+	g.schemas[name] = &OpenAPISchema{}
+	*g.schemas[name] = *g.structSchema(t)
+
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	g.addFields(schema, t)
+	sort.Strings(schema.Required)
+
+	return schema
+}
+
+func (g *schemaGenerator) addFields(schema *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addFields(schema, ft)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = g.schemaOf(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package gee
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testUser struct {
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	Email     string       `json:"email,omitempty"`
+	Tags      []string     `json:"tags,omitempty"`
+	Address   *testAddress `json:"address"`
+	CreatedAt time.Time    `json:"created_at"`
+	Friends   []testUser   `json:"friends,omitempty"`
+	password  string
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	tests := []struct {
+		name           string
+		pattern        string
+		expectedPath   string
+		expectedParams []string
+	}{
+		{
+			name:           "static",
+			pattern:        "/users",
+			expectedPath:   "/users",
+			expectedParams: []string{},
+		},
+		{
+			name:           "params",
+			pattern:        "/users/:id/posts/:postId",
+			expectedPath:   "/users/{id}/posts/{postId}",
+			expectedParams: []string{"id", "postId"},
+		},
+		{
+			name:           "wildcard",
+			pattern:        "/assets/*filepath",
+			expectedPath:   "/assets/{filepath}",
+			expectedParams: []string{"filepath"},
+		},
+		{
+			name:           "unnamed wildcard",
+			pattern:        "/static/*",
+			expectedPath:   "/static/{path}",
+			expectedParams: []string{"path"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, params := openAPIPath(tt.pattern)
+
+			if path != tt.expectedPath {
+				t.Errorf("Expected path %q, got %q", tt.expectedPath, path)
+			}
+
+			if len(params) != len(tt.expectedParams) {
+				t.Fatalf("Expected %d params, got %d", len(tt.expectedParams), len(params))
+			}
+			for i, name := range tt.expectedParams {
+				if params[i].Name != name || params[i].In != "path" || !params[i].Required {
+					t.Errorf("Unexpected param[%d] %+v", i, params[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEngine_OpenAPI(t *testing.T) {
+	e := New()
+	e.GET("/users/:id", func(c *Context) {}).Name("user.show").Doc(RouteDoc{
+		Summary:   "Show a user",
+		Tags:      []string{"users"},
+		Responses: map[int]any{http.StatusOK: testUser{}, http.StatusNotFound: nil},
+	})
+	e.POST("/users", func(c *Context) {}).Doc(RouteDoc{
+		Request:   testUser{},
+		Responses: map[int]any{http.StatusCreated: &testUser{}},
+	})
+	e.GET("/plain", func(c *Context) {})
+
+	doc := e.OpenAPI(OpenAPIInfo{Title: "test", Version: "1.0"})
+
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("Expected openapi 3.1.0, got %q", doc.OpenAPI)
+	}
+
+	show := doc.Paths["/users/{id}"]["get"]
+	if show == nil {
+		t.Fatalf("Expected GET /users/{id} in paths, got %v", doc.Paths)
+	}
+	if show.OperationID != "user.show" || show.Summary != "Show a user" {
+		t.Errorf("Unexpected operation %+v", show)
+	}
+	if ref := show.Responses["200"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/testUser" {
+		t.Errorf("Expected testUser ref, got %q", ref)
+	}
+	if show.Responses["404"].Content != nil {
+		t.Error("Expected 404 response without content")
+	}
+
+	create := doc.Paths["/users"]["post"]
+	if create == nil || create.RequestBody == nil {
+		t.Fatal("Expected POST /users with request body")
+	}
+
+	if plain := doc.Paths["/plain"]["get"]; plain == nil || plain.Responses["default"] == nil {
+		t.Error("Expected undocumented route with default response")
+	}
+
+	user := doc.Components.Schemas["testUser"]
+	if user == nil {
+		t.Fatal("Expected testUser schema in components")
+	}
+	if len(doc.Components.Schemas) != 2 {
+		t.Errorf("Expected 2 component schemas, got %d", len(doc.Components.Schemas))
+	}
+	if _, ok := user.Properties["password"]; ok {
+		t.Error("Expected unexported field to be skipped")
+	}
+	if got := user.Properties["created_at"]; got.Type != "string" || got.Format != "date-time" {
+		t.Errorf("Unexpected created_at schema %+v", got)
+	}
+	if got := user.Properties["friends"]; got.Items == nil || got.Items.Ref != "#/components/schemas/testUser" {
+		t.Errorf("Expected recursive friends ref, got %+v", got)
+	}
+	if strings.Join(user.Required, ",") != "created_at,id,name" {
+		t.Errorf("Unexpected required fields %v", user.Required)
+	}
+}
+
+func TestRouteGroup_ServeOpenAPI(t *testing.T) {
+	e := New()
+	e.ServeOpenAPI("/openapi.json", OpenAPIInfo{Title: "test", Version: "1.0"})
+	e.GET("/hello/:name", func(c *Context) {}).Doc(RouteDoc{Summary: "hello"})
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+
+	var doc OpenAPIDocument
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Paths["/hello/{name}"]; !ok {
+		t.Errorf("Expected /hello/{name} in served document, got %v", doc.Paths)
+	}
+	if _, ok := doc.Paths["/openapi.json"]; ok {
+		t.Error("Expected spec route to be hidden")
+	}
+
+	if err := e.CheckDocumented(); err != nil {
+		t.Errorf("Expected all routes documented, got %v", err)
+	}
+}
+
+func TestEngine_OpenAPI_HostDuplicates(t *testing.T) {
+	e := New()
+	e.Host("admin.example.com").GET("/x", func(c *Context) {}).Doc(RouteDoc{Summary: "admin"})
+	e.Host("api.example.com").GET("/x", func(c *Context) {}).Doc(RouteDoc{Summary: "api"})
+	e.Host("api.example.com").POST("/x", func(c *Context) {}).Doc(RouteDoc{Summary: "create"})
+
+	doc := e.OpenAPI(OpenAPIInfo{Title: "test", Version: "1.0"})
+	ops := doc.Paths["/x"]
+	if len(ops) != 2 || ops["get"].Summary != "admin" || ops["post"].Summary != "create" {
+		t.Errorf("Expected the first GET and the POST to be documented, got %+v", ops)
+	}
+}
+
+func TestEngine_CheckDocumented(t *testing.T) {
+	e := New()
+	e.GET("/documented", func(c *Context) {}).Doc(RouteDoc{Summary: "ok"})
+	e.POST("/missing", func(c *Context) {})
+
+	err := e.CheckDocumented()
+	if err == nil || !strings.Contains(err.Error(), "POST /missing") {
+		t.Errorf("Expected error naming POST /missing, got %v", err)
+	}
+}
+
+func TestOpenAPIPath_Constraints(t *testing.T) {
+	path, params := openAPIPath(`/user/{id:int}/file/{name:[a-z]+}`)
+
+	if path != "/user/{id}/file/{name}" {
+		t.Errorf("Unexpected path %q", path)
+	}
+	if len(params) != 2 || params[0].Schema.Type != "integer" || params[1].Schema.Pattern != "^(?:[a-z]+)$" {
+		t.Errorf("Unexpected params %+v %+v", params[0].Schema, params[1].Schema)
+	}
+}
@@ -0,0 +1,98 @@
+package gee
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// cleanPath resolves "." and ".." and squashes duplicate slashes while
+// keeping a trailing slash, so "//a/../b/" becomes "/b/".
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// fixPath returns the canonical form of a request path for a registered
+// route: cleaned and case-corrected when fixCase is set, with the trailing
+// slash of the route pattern when trailingSlash is set. ok is false when no
+// route would match even after fixing.
+func (r *router) fixPath(method, reqPath string, fixCase, trailingSlash bool) (string, bool) {
+	root, ok := r.load().roots[method]
+	if !ok {
+		return "", false
+	}
+
+	candidate := reqPath
+	if fixCase {
+		candidate = cleanPath(reqPath)
+	}
+
+	parts := parsePattern(candidate)
+	slash := hasTrailingSlash(candidate)
+	n := root.search(parts, 0, slash)
+	if n == nil && fixCase {
+		var fixed []string
+		n, fixed = root.searchFold(parts, 0, slash, make([]string, 0, len(parts)))
+		if n != nil {
+			candidate = "/" + strings.Join(fixed, "/")
+			if slash && len(fixed) > 0 {
+				candidate += "/"
+			}
+		}
+	}
+	if n == nil {
+		return "", false
+	}
+
+	if trailingSlash && !strings.Contains(n.pattern, "*") && candidate != "/" {
+		candidate = strings.TrimRight(candidate, "/")
+		if hasTrailingSlash(n.pattern) {
+			candidate += "/"
+		}
+	}
+
+	return candidate, true
+}
+
+// redirectPath answers with a redirect to the canonical path if the request
+// path differs from it. GET and HEAD get a 301, other methods a 308 so the
+// body is sent again.
+func (e *Engine) redirectPath(c *Context, r *router) bool {
+	if !e.RedirectFixedPath && !e.RedirectTrailingSlash {
+		return false
+	}
+
+	target, ok := r.fixPath(c.method, c.path, e.RedirectFixedPath, e.RedirectTrailingSlash)
+	if !ok || target == c.path {
+		return false
+	}
+	// without RedirectFixedPath the path is not cleaned, and a Location of
+	// //evil.com would send the browser to another host
+	target = "/" + strings.TrimLeft(target, "/")
+
+	u := *c.r.URL
+	if c.path == c.r.URL.RawPath {
+		u.Path, _ = url.PathUnescape(target)
+		u.RawPath = target
+	} else {
+		u.Path = target
+		u.RawPath = ""
+	}
+
+	code := http.StatusPermanentRedirect
+	if c.method == http.MethodGet || c.method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	debugPrintf("redirecting request %d: %s --> %s", code, c.path, target)
+	http.Redirect(c.w, c.r, u.RequestURI(), code)
+	return true
+}
@@ -0,0 +1,222 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: "/"},
+		{path: "/", expected: "/"},
+		{path: "//hello", expected: "/hello"},
+		{path: "/hello//world/", expected: "/hello/world/"},
+		{path: "/a/../hello", expected: "/hello"},
+		{path: "/../..", expected: "/"},
+		{path: "hello/./x", expected: "/hello/x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := cleanPath(tt.path); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEngine_RedirectPaths(t *testing.T) {
+	tests := []struct {
+		name             string
+		trailingSlash    bool
+		fixedPath        bool
+		method           string
+		path             string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:           "options off keep lenient matching",
+			method:         "GET",
+			path:           "/hello/",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "strip trailing slash",
+			trailingSlash:    true,
+			method:           "GET",
+			path:             "/hello/",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/hello",
+		},
+		{
+			name:             "add trailing slash",
+			trailingSlash:    true,
+			method:           "GET",
+			path:             "/dir",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/dir/",
+		},
+		{
+			name:             "non GET uses 308 and keeps query",
+			trailingSlash:    true,
+			method:           "POST",
+			path:             "/submit/?x=1",
+			expectedStatus:   http.StatusPermanentRedirect,
+			expectedLocation: "/submit?x=1",
+		},
+		{
+			name:           "registered form is served",
+			trailingSlash:  true,
+			fixedPath:      true,
+			method:         "GET",
+			path:           "/hello",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "duplicate slashes",
+			fixedPath:        true,
+			method:           "GET",
+			path:             "//hello",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/hello",
+		},
+		{
+			name:             "dot dot",
+			fixedPath:        true,
+			method:           "GET",
+			path:             "/x/../users/1",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/users/1",
+		},
+		{
+			name:             "case mismatch keeps param value",
+			fixedPath:        true,
+			method:           "GET",
+			path:             "/USERS/Bob",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/users/Bob",
+		},
+		{
+			name:             "case mismatch with wildcard",
+			fixedPath:        true,
+			method:           "GET",
+			path:             "/Assets/CSS/Main.css",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/assets/CSS/Main.css",
+		},
+		{
+			name:             "leading slashes do not redirect off-site",
+			trailingSlash:    true,
+			method:           "GET",
+			path:             "http://example.com//hello/",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/hello",
+		},
+		{
+			name:           "unknown path is still 404",
+			trailingSlash:  true,
+			fixedPath:      true,
+			method:         "GET",
+			path:           "/nope/",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.RedirectTrailingSlash = tt.trailingSlash
+			e.RedirectFixedPath = tt.fixedPath
+			ok := func(c *Context) { c.String(http.StatusOK, "OK") }
+			e.GET("/hello", ok)
+			e.GET("/dir/", ok)
+			e.POST("/submit", ok)
+			e.GET("/users/:name", ok)
+			e.GET("/assets/*filepath", ok)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if got := rr.Header().Get("Location"); got != tt.expectedLocation {
+				t.Errorf("Expected location %q, got %q", tt.expectedLocation, got)
+			}
+		})
+	}
+}
+
+func TestEngine_UseRawPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		useRawPath   bool
+		unescape     bool
+		path         string
+		expectedBody string
+	}{
+		{
+			name:         "decoded path splits on encoded slash",
+			path:         "/files/a%2Fb",
+			expectedBody: "404 NOT FOUND: /files/a/b\n",
+		},
+		{
+			name:         "raw path keeps segment and unescapes value",
+			useRawPath:   true,
+			unescape:     true,
+			path:         "/files/a%2Fb",
+			expectedBody: "name a/b",
+		},
+		{
+			name:         "raw path without unescape",
+			useRawPath:   true,
+			path:         "/files/a%2Fb",
+			expectedBody: "name a%2Fb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.UseRawPath = tt.useRawPath
+			e.UnescapePathValues = tt.unescape
+			e.GET("/files/:name", func(c *Context) {
+				c.String(http.StatusOK, "name %s", c.Param("name"))
+			})
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestEngine_BothSlashForms(t *testing.T) {
+	for _, redirect := range []bool{false, true} {
+		e := New()
+		e.RedirectTrailingSlash = redirect
+		e.GET("/hello", func(c *Context) { c.String(http.StatusOK, "plain") })
+		e.GET("/hello/", func(c *Context) { c.String(http.StatusOK, "slash") })
+
+		for path, expected := range map[string]string{"/hello": "plain", "/hello/": "slash"} {
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+			if rr.Code != http.StatusOK || rr.Body.String() != expected {
+				t.Errorf("Expected %s to be served by %q (redirect %v), got %d %q", path, expected, redirect, rr.Code, rr.Body.String())
+			}
+		}
+	}
+}
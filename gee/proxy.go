@@ -0,0 +1,362 @@
+package gee
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Balance int
+
+const (
+	RoundRobin Balance = iota
+	LeastConnections
+	ConsistentHash
+)
+
+type HealthCheck struct {
+	// Path is probed with GET on every target, any 2xx or 3xx is healthy.
+	// Active checks are off when it is empty.
+	Path     string
+	Interval time.Duration // 10s if zero
+	Timeout  time.Duration // 2s if zero
+}
+
+type ProxyOptions struct {
+	Balance Balance
+	// HashKey picks the key for ConsistentHash, the client IP by default.
+	HashKey     func(c *Context) string
+	HealthCheck HealthCheck
+	// Retries is how many other targets an idempotent request without a
+	// body is sent to after a transport error.
+	Retries int
+	// StripPrefix is removed from the request path before it is appended
+	// to the target path.
+	StripPrefix string
+	// PreserveHost forwards the client's Host instead of the target's.
+	PreserveHost bool
+	// RequestHeaders and ResponseHeaders are set on the way through, an
+	// empty value removes the header.
+	RequestHeaders  map[string]string
+	ResponseHeaders map[string]string
+	Transport       http.RoundTripper
+}
+
+type backend struct {
+	url    *url.URL
+	active atomic.Int64
+	down   atomic.Bool
+}
+
+// Proxy forwards requests to a set of targets. NewProxy builds one that can
+// be closed; ReverseProxy is the shorthand for mounting it directly.
+type Proxy struct {
+	opts     ProxyOptions
+	backends []*backend
+	ring     []ringPoint
+	next     atomic.Uint64
+	proxy    *httputil.ReverseProxy
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// ReverseProxy returns a handler for a wildcard route that forwards to
+// targets:
+//
+//	proxy := gee.ReverseProxy([]string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"}, gee.ProxyOptions{})
+//	api := r.Group("/api")
+//	api.GET("/*path", proxy)
+//	api.POST("/*path", proxy)
+//
+// Its health checks run for the life of the process, use NewProxy to be
+// able to stop them.
+func ReverseProxy(targets []string, opts ProxyOptions) Handler {
+	return NewProxy(targets, opts).Handle
+}
+
+// NewProxy panics on an invalid target, like route registration does.
+// WebSocket upgrades and server-sent events are passed through as they are.
+func NewProxy(targets []string, opts ProxyOptions) *Proxy {
+	if len(targets) == 0 {
+		panic("gee: ReverseProxy needs at least one target")
+	}
+
+	p := &Proxy{opts: opts, stop: make(chan struct{})}
+	for _, target := range targets {
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			panic(fmt.Sprintf("gee: invalid proxy target %q", target))
+		}
+		p.backends = append(p.backends, &backend{url: u})
+	}
+	p.ring = newHashRing(p.backends, 64)
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite:        p.rewrite,
+		Transport:      opts.Transport,
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			// nothing has been written yet, Handle decides whether to retry
+			r.Context().Value(proxyAttemptKey{}).(*proxyAttempt).err = err
+		},
+	}
+
+	if opts.HealthCheck.Path != "" {
+		go p.healthLoop()
+	}
+
+	return p
+}
+
+// Close stops the active health checks.
+func (p *Proxy) Close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+type proxyAttemptKey struct{}
+
+type proxyAttempt struct {
+	backend        *backend
+	forwardedFor   string
+	forwardedHost  string
+	forwardedProto string
+	err            error
+}
+
+// Handle answers 503 when no target is healthy and 502 when the last
+// attempt failed.
+func (p *Proxy) Handle(c *Context) {
+	attempt := p.newAttempt(c)
+	r := c.r.WithContext(context.WithValue(c.r.Context(), proxyAttemptKey{}, attempt))
+
+	retries := 0
+	if retryable(c.r) {
+		retries = p.opts.Retries
+	}
+
+	tried := make(map[*backend]bool)
+	for i := 0; i <= retries; i++ {
+		b := p.pick(c, tried)
+		if b == nil {
+			break
+		}
+		tried[b] = true
+
+		attempt.backend, attempt.err = b, nil
+		p.serve(c.w, r, b)
+		if attempt.err == nil || r.Context().Err() != nil {
+			return
+		}
+	}
+
+	if attempt.err != nil {
+		c.Fail(http.StatusBadGateway, "bad gateway")
+		return
+	}
+	c.Fail(http.StatusServiceUnavailable, "no healthy upstream")
+}
+
+func (p *Proxy) serve(w http.ResponseWriter, r *http.Request, b *backend) {
+	b.active.Add(1)
+	defer b.active.Add(-1)
+
+	p.proxy.ServeHTTP(w, r)
+}
+
+// newAttempt works out the X-Forwarded-* values. What the client sent is
+// only kept when it came through a trusted proxy.
+func (p *Proxy) newAttempt(c *Context) *proxyAttempt {
+	attempt := &proxyAttempt{
+		forwardedFor:   c.RemoteIP(),
+		forwardedHost:  c.r.Host,
+		forwardedProto: "http",
+	}
+	if c.r.TLS != nil {
+		attempt.forwardedProto = "https"
+	}
+
+	if !c.fromTrustedProxy() {
+		return attempt
+	}
+
+	if prior := c.r.Header.Values("X-Forwarded-For"); len(prior) > 0 {
+		attempt.forwardedFor = strings.Join(prior, ", ") + ", " + attempt.forwardedFor
+	}
+	if host := c.r.Header.Get("X-Forwarded-Host"); host != "" {
+		attempt.forwardedHost = host
+	}
+	if proto := c.r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		attempt.forwardedProto = proto
+	}
+
+	return attempt
+}
+
+func (p *Proxy) rewrite(pr *httputil.ProxyRequest) {
+	attempt := pr.In.Context().Value(proxyAttemptKey{}).(*proxyAttempt)
+
+	if prefix := p.opts.StripPrefix; prefix != "" {
+		pr.Out.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(pr.Out.URL.Path, prefix), "/")
+		if pr.Out.URL.RawPath != "" {
+			pr.Out.URL.RawPath = "/" + strings.TrimLeft(strings.TrimPrefix(pr.Out.URL.RawPath, prefix), "/")
+		}
+	}
+	pr.SetURL(attempt.backend.url)
+	if p.opts.PreserveHost {
+		pr.Out.Host = pr.In.Host
+	}
+
+	pr.Out.Header.Set("X-Forwarded-For", attempt.forwardedFor)
+	pr.Out.Header.Set("X-Forwarded-Host", attempt.forwardedHost)
+	pr.Out.Header.Set("X-Forwarded-Proto", attempt.forwardedProto)
+	setHeaders(pr.Out.Header, p.opts.RequestHeaders)
+}
+
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	setHeaders(resp.Header, p.opts.ResponseHeaders)
+	return nil
+}
+
+func setHeaders(header http.Header, values map[string]string) {
+	for k, v := range values {
+		if v == "" {
+			header.Del(k)
+			continue
+		}
+		header.Set(k, v)
+	}
+}
+
+// retryable is true for idempotent methods whose body can't have been
+// half sent to the failed target.
+func retryable(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return r.ContentLength == 0
+	}
+	return false
+}
+
+func (p *Proxy) pick(c *Context, tried map[*backend]bool) *backend {
+	usable := func(b *backend) bool {
+		return !tried[b] && !b.down.Load()
+	}
+
+	if p.opts.Balance == ConsistentHash {
+		key := c.ClientIP()
+		if p.opts.HashKey != nil {
+			key = p.opts.HashKey(c)
+		}
+		return p.ringGet(key, usable)
+	}
+
+	// round robin, and the tie-break for least connections
+	n := uint64(len(p.backends))
+	start := p.next.Add(1) - 1
+	var best *backend
+	for i := uint64(0); i < n; i++ {
+		b := p.backends[(start+i)%n]
+		if !usable(b) {
+			continue
+		}
+		if p.opts.Balance != LeastConnections {
+			return b
+		}
+		if best == nil || b.active.Load() < best.active.Load() {
+			best = b
+		}
+	}
+
+	return best
+}
+
+type ringPoint struct {
+	hash    uint32
+	backend *backend
+}
+
+func newHashRing(backends []*backend, replicas int) []ringPoint {
+	ring := make([]ringPoint, 0, len(backends)*replicas)
+	for _, b := range backends {
+		for i := range replicas {
+			hash := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + b.url.String()))
+			ring = append(ring, ringPoint{hash: hash, backend: b})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+
+	return ring
+}
+
+// ringGet walks clockwise from the key's hash to the first usable target,
+// so a target going down only moves the keys it owned.
+func (p *Proxy) ringGet(key string, usable func(*backend) bool) *backend {
+	hash := crc32.ChecksumIEEE([]byte(key))
+	idx := sort.Search(len(p.ring), func(i int) bool {
+		return p.ring[i].hash >= hash
+	})
+
+	for i := range len(p.ring) {
+		if b := p.ring[(idx+i)%len(p.ring)].backend; usable(b) {
+			return b
+		}
+	}
+
+	return nil
+}
+
+func (p *Proxy) healthLoop() {
+	hc := p.opts.HealthCheck
+	if hc.Interval <= 0 {
+		hc.Interval = 10 * time.Second
+	}
+	if hc.Timeout <= 0 {
+		hc.Timeout = 2 * time.Second
+	}
+	client := &http.Client{
+		Transport: p.opts.Transport,
+		Timeout:   hc.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	ticker := time.NewTicker(hc.Interval)
+	defer ticker.Stop()
+
+	for {
+		var wg sync.WaitGroup
+		for _, b := range p.backends {
+			wg.Go(func() {
+				b.down.Store(!probe(client, b.url, hc.Path))
+			})
+		}
+		wg.Wait()
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func probe(client *http.Client, target *url.URL, path string) bool {
+	resp, err := client.Get(target.JoinPath(path).String())
+	if err != nil {
+		return false
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
@@ -0,0 +1,333 @@
+package gee
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newUpstream(t *testing.T, name string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", name)
+		w.Header().Set("X-Internal", "secret")
+		fmt.Fprintf(w, "%s %s xff=%s host=%s proto=%s tenant=%s",
+			name, r.URL.Path, r.Header.Get("X-Forwarded-For"), r.Header.Get("X-Forwarded-Host"),
+			r.Header.Get("X-Forwarded-Proto"), r.Header.Get("X-Tenant"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func proxyEngine(proxy Handler) *Engine {
+	e := New()
+	api := e.Group("/api")
+	for _, method := range mountMethods {
+		api.Handle(method, "/*path", proxy)
+	}
+	return e
+}
+
+func proxyGet(e *Engine, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestReverseProxy_RoundRobinAndRewrite(t *testing.T) {
+	a, b := newUpstream(t, "a"), newUpstream(t, "b")
+	proxy := ReverseProxy([]string{a.URL, b.URL + "/base"}, ProxyOptions{
+		StripPrefix:     "/api",
+		RequestHeaders:  map[string]string{"X-Tenant": "acme"},
+		ResponseHeaders: map[string]string{"X-Internal": "", "X-Proxy": "gee"},
+	})
+	e := proxyEngine(proxy)
+
+	tests := []struct {
+		expectedBody string
+	}{
+		{expectedBody: "a /users/1 xff=192.0.2.1 host=example.com proto=http tenant=acme"},
+		{expectedBody: "b /base/users/1 xff=192.0.2.1 host=example.com proto=http tenant=acme"},
+		{expectedBody: "a /users/1 xff=192.0.2.1 host=example.com proto=http tenant=acme"},
+	}
+
+	for i, tt := range tests {
+		rr := proxyGet(e, "/api/users/1")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status code %d, got %d", i, http.StatusOK, rr.Code)
+		}
+		if rr.Body.String() != tt.expectedBody {
+			t.Errorf("Request %d: expected body %q, got %q", i, tt.expectedBody, rr.Body.String())
+		}
+		if rr.Header().Get("X-Internal") != "" || rr.Header().Get("X-Proxy") != "gee" {
+			t.Errorf("Request %d: expected rewritten response headers, got %v", i, rr.Header())
+		}
+	}
+}
+
+func TestReverseProxy_ForwardedFromTrustedProxy(t *testing.T) {
+	a := newUpstream(t, "a")
+	e := proxyEngine(ReverseProxy([]string{a.URL}, ProxyOptions{}))
+	e.SetTrustedProxies([]string{"10.0.0.0/8"})
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		expectedBody string
+	}{
+		{
+			name:         "trusted peer keeps the chain",
+			remoteAddr:   "10.0.0.1:1234",
+			expectedBody: "a /api/x xff=203.0.113.9, 10.0.0.1 host=public.example proto=https tenant=",
+		},
+		{
+			name:         "untrusted peer is not believed",
+			remoteAddr:   "192.0.2.1:1234",
+			expectedBody: "a /api/x xff=192.0.2.1 host=example.com proto=http tenant=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/x", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			req.Header.Set("X-Forwarded-Host", "public.example")
+			req.Header.Set("X-Forwarded-Proto", "https")
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestReverseProxy_LeastConnections(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("slow"))
+	}))
+	defer slow.Close()
+	fast := newUpstream(t, "fast")
+
+	e := proxyEngine(ReverseProxy([]string{slow.URL, fast.URL}, ProxyOptions{Balance: LeastConnections}))
+
+	done := make(chan struct{})
+	go func() {
+		proxyGet(e, "/api/slow")
+		close(done)
+	}()
+	<-started
+
+	for i := 0; i < 3; i++ {
+		if rr := proxyGet(e, "/api/x"); !strings.HasPrefix(rr.Body.String(), "fast") {
+			t.Errorf("Request %d: expected the idle target, got %q", i, rr.Body.String())
+		}
+	}
+
+	close(release)
+	<-done
+}
+
+func TestReverseProxy_ConsistentHash(t *testing.T) {
+	upstreams := []string{newUpstream(t, "a").URL, newUpstream(t, "b").URL, newUpstream(t, "c").URL}
+	p := NewProxy(upstreams, ProxyOptions{
+		Balance: ConsistentHash,
+		HashKey: func(c *Context) string { return c.Query("user") },
+	})
+	e := proxyEngine(p.Handle)
+
+	owners := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		first := proxyGet(e, "/api/x?user="+user).Header().Get("X-Upstream")
+		if again := proxyGet(e, "/api/x?user="+user).Header().Get("X-Upstream"); again != first {
+			t.Errorf("Expected %s to stick to %s, got %s", user, first, again)
+		}
+		owners[user] = first
+	}
+
+	// taking a target down only moves the keys it owned
+	p.backends[0].down.Store(true)
+	for user, owner := range owners {
+		got := proxyGet(e, "/api/x?user="+user).Header().Get("X-Upstream")
+		if owner != "a" && got != owner {
+			t.Errorf("Expected %s to stay on %s, got %s", user, owner, got)
+		}
+		if got == "a" {
+			t.Errorf("Expected %s to move off the down target", user)
+		}
+	}
+}
+
+func TestReverseProxy_Retries(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	live := newUpstream(t, "live")
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		retries        int
+		expectedStatus int
+	}{
+		{name: "GET is retried", method: "GET", retries: 1, expectedStatus: http.StatusOK},
+		{name: "DELETE is retried", method: "DELETE", retries: 1, expectedStatus: http.StatusOK},
+		{name: "POST is not retried", method: "POST", body: "x", retries: 1, expectedStatus: http.StatusBadGateway},
+		{name: "no retries configured", method: "GET", retries: 0, expectedStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := proxyEngine(ReverseProxy([]string{dead.URL, live.URL}, ProxyOptions{Retries: tt.retries}))
+			req := httptest.NewRequest(tt.method, "/api/x", strings.NewReader(tt.body))
+			if tt.body == "" {
+				req = httptest.NewRequest(tt.method, "/api/x", nil)
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestReverseProxy_HealthCheck(t *testing.T) {
+	var mu sync.Mutex
+	healthy := map[string]bool{"a": true, "b": false}
+	upstream := func(name string) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/healthz" {
+				mu.Lock()
+				ok := healthy[name]
+				mu.Unlock()
+				if !ok {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+				return
+			}
+			w.Write([]byte(name))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	a, b := upstream("a"), upstream("b")
+
+	p := NewProxy([]string{a.URL, b.URL}, ProxyOptions{
+		HealthCheck: HealthCheck{Path: "/healthz", Interval: 10 * time.Millisecond},
+	})
+	defer p.Close()
+	e := proxyEngine(p.Handle)
+
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal("Timed out waiting for health checks")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	waitFor(func() bool { return p.backends[1].down.Load() })
+	for i := 0; i < 4; i++ {
+		if rr := proxyGet(e, "/api/x"); rr.Body.String() != "a" {
+			t.Errorf("Request %d: expected only the healthy target, got %q", i, rr.Body.String())
+		}
+	}
+
+	mu.Lock()
+	healthy["a"] = false
+	mu.Unlock()
+	waitFor(func() bool { return p.backends[0].down.Load() })
+	if rr := proxyGet(e, "/api/x"); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+}
+
+func TestReverseProxy_ServerSentEvents(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprint(w, "data: second\n\n")
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	srv := httptest.NewServer(proxyEngine(ReverseProxy([]string{upstream.URL}, ProxyOptions{})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// the first event must arrive while the upstream is still blocked
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "data: first\n" {
+		t.Errorf("Expected the first event to be flushed, got %q, %v", line, err)
+	}
+}
+
+func TestReverseProxy_WebSocket(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "expected upgrade", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+
+		// echo one line back to prove the tunnel carries raw bytes
+		line, _ := rw.ReadString('\n')
+		rw.WriteString("echo " + line)
+		rw.Flush()
+	}))
+	defer upstream.Close()
+
+	srv := httptest.NewServer(proxyEngine(ReverseProxy([]string{upstream.URL}, ProxyOptions{})))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/api/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status code %d, got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+	conn := resp.Body.(io.ReadWriteCloser)
+	conn.Write([]byte("hello\n"))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "echo hello\n" {
+		t.Errorf("Expected %q, got %q, %v", "echo hello\n", line, err)
+	}
+}
@@ -0,0 +1,52 @@
+package gee
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	RequestIDKey    = "gee.requestID"
+)
+
+// RequestID reuses the caller's X-Request-ID when it looks sane, generates a
+// new one otherwise, and echoes it back on the response.
+func RequestID() Handler {
+	return func(c *Context) {
+		id := c.r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.SetHeader(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+func (c *Context) RequestID() string {
+	id, _ := c.keys[RequestIDKey].(string)
+	return id
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// only accept short printable ASCII ids so a client can't inject into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
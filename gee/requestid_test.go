@@ -0,0 +1,65 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		incoming   string
+		expectSame bool
+	}{
+		{
+			name:       "accept incoming id",
+			incoming:   "abc-123",
+			expectSame: true,
+		},
+		{
+			name:       "generate when missing",
+			incoming:   "",
+			expectSame: false,
+		},
+		{
+			name:       "replace invalid id",
+			incoming:   "bad id\n",
+			expectSame: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.Use(RequestID())
+
+			var seen string
+			e.GET("/test", func(c *Context) {
+				seen = c.RequestID()
+				c.String(http.StatusOK, "OK")
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+
+			e.ServeHTTP(rr, req)
+
+			echoed := rr.Header().Get(RequestIDHeader)
+			if echoed == "" || echoed != seen {
+				t.Errorf("Expected echoed id %q to match context id %q", echoed, seen)
+			}
+
+			if tt.expectSame && seen != tt.incoming {
+				t.Errorf("Expected id %q, got %q", tt.incoming, seen)
+			}
+
+			if !tt.expectSame && (seen == tt.incoming || len(seen) != 32) {
+				t.Errorf("Expected generated id, got %q", seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package gee
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loveRyujin/gee/internal/lru"
+	"github.com/loveRyujin/gee/internal/singleflight"
+)
+
+const defaultResponseCacheBytes = 64 << 20 // 64 MB
+
+// ResponseCache is the byte-bounded LRU behind CacheResponses. Share one
+// through CacheOptions.Store to purge it from outside the middleware.
+type ResponseCache struct {
+	mu    sync.Mutex
+	lru   *lru.Cache
+	group singleflight.Group
+}
+
+func NewResponseCache(maxBytes int64) *ResponseCache {
+	return &ResponseCache{lru: lru.New(maxBytes, nil)}
+}
+
+type cachedResponse struct {
+	path    string
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+func (r *cachedResponse) Len() int {
+	n := len(r.path) + len(r.body)
+	for k, vs := range r.header {
+		n += len(k)
+		for _, v := range vs {
+			n += len(v)
+		}
+	}
+	return n
+}
+
+func (rc *ResponseCache) get(key string) (*cachedResponse, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	v, ok := rc.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	resp := v.(*cachedResponse)
+	if time.Now().After(resp.expires) {
+		rc.lru.Remove(key)
+		return nil, false
+	}
+	return resp, true
+}
+
+func (rc *ResponseCache) add(key string, resp *cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.lru.Add(key, resp)
+}
+
+// PurgePrefix drops every cached response whose path starts with prefix
+// and returns how many were dropped.
+func (rc *ResponseCache) PurgePrefix(prefix string) int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.lru.RemoveFunc(func(key string, v lru.Value) bool {
+		return strings.HasPrefix(v.(*cachedResponse).path, prefix)
+	})
+}
+
+func (rc *ResponseCache) Len() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.lru.Len()
+}
+
+type CacheOptions struct {
+	// Store holds the responses, a private 64 MB cache is used if nil.
+	Store *ResponseCache
+	// QueryParams are the query parameters that are part of the key, all
+	// others are ignored.
+	QueryParams []string
+	// VaryHeaders are the request headers that are part of the key. A
+	// response whose Vary names any other header is not cached.
+	VaryHeaders []string
+}
+
+// CacheResponses stores successful GET responses for ttl and replays them.
+// Concurrent misses for the same key run the handlers once. Requests with
+// Cache-Control: no-store bypass the cache, and responses with no-store,
+// private or Set-Cookie are never stored.
+func CacheResponses(ttl time.Duration, opts CacheOptions) Handler {
+	store := opts.Store
+	if store == nil {
+		store = NewResponseCache(defaultResponseCacheBytes)
+	}
+	vary := make(map[string]bool, len(opts.VaryHeaders))
+	for _, h := range opts.VaryHeaders {
+		vary[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(c *Context) {
+		if c.method != http.MethodGet || hasCacheDirective(c.r.Header, "no-store") {
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c.r, opts.QueryParams, opts.VaryHeaders)
+		if resp, ok := store.get(key); ok {
+			replayResponse(c, resp, "HIT")
+			return
+		}
+
+		var buf *bufferedWriter
+		v, _ := store.group.Do(key, func() (any, error) {
+			// only what the wrapped chain set is stored; headers from outer
+			// middleware, like X-Request-ID, belong to each request
+			before := c.w.Header().Clone()
+			buf = c.bufferResponse()
+			if !cacheable(buf.Status(), c.w.Header(), vary) {
+				return nil, nil
+			}
+			resp := &cachedResponse{
+				path:    c.r.URL.Path,
+				status:  buf.Status(),
+				header:  headerChanges(before, c.w.Header()),
+				body:    buf.body.Bytes(),
+				expires: time.Now().Add(ttl),
+			}
+			store.add(key, resp)
+			return resp, nil
+		})
+
+		resp, _ := v.(*cachedResponse)
+		switch {
+		case buf != nil:
+			c.SetHeader("X-Cache", "MISS")
+			buf.flushTo(c.w)
+		case resp != nil:
+			replayResponse(c, resp, "HIT")
+		default:
+			// the shared response was not cacheable, so it may be private
+			// to the leader; serve this request on its own
+			c.Next()
+		}
+	}
+}
+
+func replayResponse(c *Context, resp *cachedResponse, state string) {
+	header := c.w.Header()
+	for k, vs := range resp.header {
+		header[k] = append([]string(nil), vs...)
+	}
+	header.Set("X-Cache", state)
+	c.w.WriteHeader(resp.status)
+	c.w.Write(resp.body)
+	c.Abort()
+}
+
+func cacheable(status int, header http.Header, vary map[string]bool) bool {
+	switch status {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent:
+	default:
+		return false
+	}
+
+	if hasCacheDirective(header, "no-store") || hasCacheDirective(header, "private") {
+		return false
+	}
+	if len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+
+	for _, value := range header.Values("Vary") {
+		for _, h := range strings.Split(value, ",") {
+			h = strings.TrimSpace(h)
+			if h == "*" || (h != "" && !vary[http.CanonicalHeaderKey(h)]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func hasCacheDirective(header http.Header, directive string) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if strings.EqualFold(name, directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// responseCacheKey is "METHOD host path?selected-query" followed by the
+// selected request headers, each part escaped so they cannot run into each
+// other. The host is part of it because Engine.Host serves different
+// handlers on the same path.
+func responseCacheKey(r *http.Request, params, headers []string) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(url.QueryEscape(strings.ToLower(r.Host)))
+	b.WriteByte(' ')
+	b.WriteString(r.URL.EscapedPath())
+
+	query := r.URL.Query()
+	selected := make(url.Values)
+	for _, p := range params {
+		if vs, ok := query[p]; ok {
+			selected[p] = vs
+		}
+	}
+	if len(selected) > 0 {
+		b.WriteByte('?')
+		b.WriteString(selected.Encode())
+	}
+
+	sorted := append([]string(nil), headers...)
+	sort.Strings(sorted)
+	for _, h := range sorted {
+		b.WriteByte('\n')
+		b.WriteString(http.CanonicalHeaderKey(h))
+		b.WriteByte(':')
+		b.WriteString(url.QueryEscape(strings.Join(r.Header.Values(h), ",")))
+	}
+
+	return b.String()
+}
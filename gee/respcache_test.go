@@ -0,0 +1,248 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheResponses(t *testing.T) {
+	tests := []struct {
+		name          string
+		requests      []func(r *http.Request)
+		handler       func(c *Context)
+		path          func(i int) string
+		expectedCalls int32
+		expectedCache []string
+	}{
+		{
+			name:          "second request is a hit",
+			path:          func(i int) string { return "/data" },
+			expectedCalls: 1,
+			expectedCache: []string{"MISS", "HIT"},
+		},
+		{
+			name:          "unselected query params share an entry",
+			path:          func(i int) string { return "/data?page=1&_=" + strconv.Itoa(i) },
+			expectedCalls: 1,
+			expectedCache: []string{"MISS", "HIT"},
+		},
+		{
+			name:          "selected query params split entries",
+			path:          func(i int) string { return "/data?page=" + strconv.Itoa(i) },
+			expectedCalls: 2,
+			expectedCache: []string{"MISS", "MISS"},
+		},
+		{
+			name: "vary header splits entries",
+			requests: []func(r *http.Request){
+				func(r *http.Request) { r.Header.Set("Accept-Language", "en") },
+				func(r *http.Request) { r.Header.Set("Accept-Language", "fr") },
+				func(r *http.Request) { r.Header.Set("Accept-Language", "en") },
+			},
+			path:          func(i int) string { return "/data" },
+			expectedCalls: 2,
+			expectedCache: []string{"MISS", "MISS", "HIT"},
+		},
+		{
+			name: "request no-store bypasses",
+			requests: []func(r *http.Request){
+				func(r *http.Request) {},
+				func(r *http.Request) { r.Header.Set("Cache-Control", "no-store") },
+			},
+			path:          func(i int) string { return "/data" },
+			expectedCalls: 2,
+			expectedCache: []string{"MISS", ""},
+		},
+		{
+			name: "response no-store is not stored",
+			handler: func(c *Context) {
+				c.CacheControl("no-store")
+				c.String(http.StatusOK, "secret")
+			},
+			path:          func(i int) string { return "/data" },
+			expectedCalls: 2,
+			expectedCache: []string{"MISS", "MISS"},
+		},
+		{
+			name: "unknown vary is not stored",
+			handler: func(c *Context) {
+				c.SetHeader("Vary", "Authorization")
+				c.String(http.StatusOK, "per user")
+			},
+			path:          func(i int) string { return "/data" },
+			expectedCalls: 2,
+			expectedCache: []string{"MISS", "MISS"},
+		},
+		{
+			name: "errors are not stored",
+			handler: func(c *Context) {
+				c.String(http.StatusInternalServerError, "oops")
+			},
+			path:          func(i int) string { return "/data" },
+			expectedCalls: 2,
+			expectedCache: []string{"MISS", "MISS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			handler := tt.handler
+			if handler == nil {
+				handler = func(c *Context) {
+					c.SetHeader("X-Handler", "true")
+					c.String(http.StatusOK, "data")
+				}
+			}
+
+			e := New()
+			e.Use(CacheResponses(time.Minute, CacheOptions{
+				QueryParams: []string{"page"},
+				VaryHeaders: []string{"accept-language"},
+			}))
+			e.GET("/data", func(c *Context) {
+				atomic.AddInt32(&calls, 1)
+				handler(c)
+			})
+
+			n := len(tt.expectedCache)
+			for i := 0; i < n; i++ {
+				req := httptest.NewRequest("GET", tt.path(i), nil)
+				if tt.requests != nil {
+					tt.requests[i](req)
+				}
+				rr := httptest.NewRecorder()
+				e.ServeHTTP(rr, req)
+
+				if got := rr.Header().Get("X-Cache"); got != tt.expectedCache[i] {
+					t.Errorf("Request %d: expected X-Cache %q, got %q", i, tt.expectedCache[i], got)
+				}
+				if tt.handler == nil && (rr.Body.String() != "data" || rr.Header().Get("X-Handler") != "true") {
+					t.Errorf("Request %d: unexpected response %q %v", i, rr.Body.String(), rr.Header())
+				}
+			}
+
+			if calls != tt.expectedCalls {
+				t.Errorf("Expected %d handler calls, got %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestCacheResponses_TTL(t *testing.T) {
+	var calls int32
+	e := New()
+	e.Use(CacheResponses(20*time.Millisecond, CacheOptions{}))
+	e.GET("/data", func(c *Context) {
+		atomic.AddInt32(&calls, 1)
+		c.String(http.StatusOK, "data")
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/data", nil))
+	time.Sleep(30 * time.Millisecond)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/data", nil))
+
+	if calls != 2 {
+		t.Errorf("Expected expired entry to be refreshed, got %d calls", calls)
+	}
+}
+
+func TestCacheResponses_CollapsesMisses(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	e := New()
+	e.Use(CacheResponses(time.Minute, CacheOptions{}))
+	e.GET("/slow", func(c *Context) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		c.String(http.StatusOK, "slow")
+	})
+
+	const n = 10
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, httptest.NewRequest("GET", "/slow", nil))
+			if rr.Body.String() != "slow" {
+				t.Errorf("Expected body %q, got %q", "slow", rr.Body.String())
+			}
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected 1 handler call, got %d", calls)
+	}
+}
+
+func TestResponseCache_PurgePrefix(t *testing.T) {
+	store := NewResponseCache(1 << 20)
+	e := New()
+	e.Use(CacheResponses(time.Minute, CacheOptions{Store: store}))
+	e.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "user") })
+	e.GET("/posts/:id", func(c *Context) { c.String(http.StatusOK, "post") })
+
+	for _, path := range []string{"/users/1", "/users/2", "/posts/1"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if removed := store.PurgePrefix("/users/"); removed != 2 {
+		t.Errorf("Expected 2 purged entries, got %d", removed)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Expected 1 entry left, got %d", store.Len())
+	}
+}
+
+func TestCacheResponses_KeyIncludesHost(t *testing.T) {
+	e := New()
+	e.Use(CacheResponses(time.Minute, CacheOptions{}))
+	e.Host("admin.example.com").GET("/x", func(c *Context) { c.String(http.StatusOK, "admin") })
+	e.Host("api.example.com").GET("/x", func(c *Context) { c.String(http.StatusOK, "api") })
+
+	for _, host := range []string{"admin.example.com", "api.example.com"} {
+		req := httptest.NewRequest("GET", "/x", nil)
+		req.Host = host
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, req)
+
+		expected := strings.Split(host, ".")[0]
+		if rr.Body.String() != expected || rr.Header().Get("X-Cache") != "MISS" {
+			t.Errorf("Expected %s to get a MISS with %q, got %s %q", host, expected, rr.Header().Get("X-Cache"), rr.Body.String())
+		}
+	}
+}
+
+func TestCacheResponses_KeepsOuterHeaders(t *testing.T) {
+	e := New()
+	e.Use(RequestID(), CacheResponses(time.Minute, CacheOptions{}))
+	e.GET("/data", func(c *Context) {
+		c.SetHeader("X-Handler", "data")
+		c.String(http.StatusOK, "data")
+	})
+
+	ids := make(map[string]bool)
+	for _, state := range []string{"MISS", "HIT"} {
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, httptest.NewRequest("GET", "/data", nil))
+		if rr.Header().Get("X-Cache") != state {
+			t.Errorf("Expected X-Cache %s, got %s", state, rr.Header().Get("X-Cache"))
+		}
+		if rr.Header().Get("X-Handler") != "data" {
+			t.Errorf("Expected the handler's headers to be replayed, got %v", rr.Header())
+		}
+		ids[rr.Header().Get("X-Request-ID")] = true
+	}
+	if len(ids) != 2 {
+		t.Errorf("Expected each response to keep its own X-Request-ID, got %v", ids)
+	}
+}
@@ -0,0 +1,127 @@
+package gee
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+)
+
+// responseWriter records the status and size of what the handlers wrote so
+// middleware running after Next can inspect it.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w}
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("gee: ResponseWriter does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// bufferedWriter holds the whole response in memory so middleware can
+// inspect or replace it before anything reaches the client.
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedWriter(w http.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{header: w.Header()}
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// flushTo sends the buffered status and body to w, whose header map is the
+// one the handlers already filled in.
+func (w *bufferedWriter) flushTo(dst http.ResponseWriter) {
+	dst.WriteHeader(w.Status())
+	dst.Write(w.body.Bytes())
+}
+
+// bufferResponse runs the rest of the chain against a bufferedWriter and
+// returns it; the caller decides what to send.
+func (c *Context) bufferResponse() *bufferedWriter {
+	w := c.w
+	buf := newBufferedWriter(w)
+	c.w = buf
+	defer func() { c.w = w }()
+
+	c.Next()
+	return buf
+}
+
+// headerChanges returns the entries of after that are new or differ from
+// before, i.e. what the buffered chain itself set on the response.
+func headerChanges(before, after http.Header) http.Header {
+	changed := make(http.Header)
+	for k, vs := range after {
+		if !slices.Equal(before[k], vs) {
+			changed[k] = slices.Clone(vs)
+		}
+	}
+	return changed
+}
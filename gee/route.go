@@ -0,0 +1,144 @@
+package gee
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+type Route struct {
+	method   string
+	pattern  string
+	name     string
+	handlers HandlerChain
+	host     *hostRouter
+	doc      *RouteDoc
+	engine   *Engine
+}
+
+type RouteInfo struct {
+	Host        string
+	Method      string
+	Pattern     string
+	Name        string
+	Handler     string
+	Middlewares int
+}
+
+// Routes lists the registered routes in registration order. Middlewares
+// counts the group and route-level handlers that run in front of the
+// route's last handler.
+func (e *Engine) Routes() []RouteInfo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	state := e.state.Load()
+	infos := make([]RouteInfo, 0, len(e.routes))
+	for _, rt := range e.routes {
+		var host string
+		if rt.host != nil {
+			host = rt.host.pattern
+		}
+
+		infos = append(infos, RouteInfo{
+			Host:        host,
+			Method:      rt.method,
+			Pattern:     rt.pattern,
+			Name:        rt.name,
+			Handler:     nameOfFunction(rt.handlers[len(rt.handlers)-1]),
+			Middlewares: len(state.groupHandlers(rt.host, rt.pattern)) + len(rt.handlers) - 1,
+		})
+	}
+
+	return infos
+}
+
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+func (rt *Route) Method() string {
+	return rt.method
+}
+
+func (rt *Route) Pattern() string {
+	return rt.pattern
+}
+
+// Name registers the route under name so Engine.URLFor can build its URL.
+// Names are unique per engine, reusing one panics.
+func (rt *Route) Name(name string) *Route {
+	e := rt.engine
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.namedRoutes[name]; ok {
+		panic(fmt.Sprintf("gee: route name %q already registered", name))
+	}
+	if e.namedRoutes == nil {
+		e.namedRoutes = make(map[string]*Route)
+	}
+
+	rt.name = name
+	e.namedRoutes[name] = rt
+	return rt
+}
+
+// URLFor builds the path of a named route. params are key/value pairs that
+// fill in its :param, {param:constraint} and *wildcard segments, e.g.
+//
+//	e.URLFor("user.show", "name", "geektutu")
+func (e *Engine) URLFor(name string, params ...string) (string, error) {
+	e.mu.Lock()
+	rt, ok := e.namedRoutes[name]
+	e.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("gee: no route named %q", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("gee: URLFor %q: odd number of params", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	parts := strings.Split(rt.pattern, "/")
+	for i, part := range parts {
+		if part == "" || (!isParamPart(part) && part[0] != '*') {
+			continue
+		}
+
+		key := paramName(part)
+		value, ok := values[key]
+		if !ok && key != "" {
+			return "", fmt.Errorf("gee: URLFor %q: missing param %q", name, key)
+		}
+		delete(values, key)
+
+		if part[0] != '*' {
+			if re := parseConstraint(part); re != nil && !re.MatchString(value) {
+				return "", fmt.Errorf("gee: URLFor %q: param %q does not match %s", name, key, part)
+			}
+			parts[i] = url.PathEscape(value)
+			continue
+		}
+
+		segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+		for j, seg := range segments {
+			segments[j] = url.PathEscape(seg)
+		}
+		parts[i] = strings.Join(segments, "/")
+		parts = parts[:i+1]
+		break
+	}
+
+	for key := range values {
+		return "", fmt.Errorf("gee: URLFor %q: unknown param %q", name, key)
+	}
+
+	return strings.Join(parts, "/"), nil
+}
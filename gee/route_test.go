@@ -0,0 +1,141 @@
+package gee
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEngine_URLFor(t *testing.T) {
+	e := New()
+	e.GET("/hello/:name", func(c *Context) {}).Name("hello")
+	e.GET("/assets/*filepath", func(c *Context) {}).Name("assets")
+	v1 := e.Group("/v1")
+	v1.GET("/users/:id/posts/:postId", func(c *Context) {}).Name("post.show")
+	v1.POST("/users", func(c *Context) {}).Name("user.create")
+
+	tests := []struct {
+		name        string
+		route       string
+		params      []string
+		expectedURL string
+		expectedErr bool
+	}{
+		{
+			name:        "static route",
+			route:       "user.create",
+			expectedURL: "/v1/users",
+		},
+		{
+			name:        "param route",
+			route:       "hello",
+			params:      []string{"name", "geektutu"},
+			expectedURL: "/hello/geektutu",
+		},
+		{
+			name:        "param is escaped",
+			route:       "hello",
+			params:      []string{"name", "a b/c"},
+			expectedURL: "/hello/a%20b%2Fc",
+		},
+		{
+			name:        "group prefix and multiple params",
+			route:       "post.show",
+			params:      []string{"id", "1", "postId", "2"},
+			expectedURL: "/v1/users/1/posts/2",
+		},
+		{
+			name:        "wildcard keeps slashes",
+			route:       "assets",
+			params:      []string{"filepath", "css/main file.css"},
+			expectedURL: "/assets/css/main%20file.css",
+		},
+		{
+			name:        "missing param",
+			route:       "hello",
+			expectedErr: true,
+		},
+		{
+			name:        "unknown param",
+			route:       "hello",
+			params:      []string{"name", "a", "extra", "b"},
+			expectedErr: true,
+		},
+		{
+			name:        "odd params",
+			route:       "hello",
+			params:      []string{"name"},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown route",
+			route:       "nope",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := e.URLFor(tt.route, tt.params...)
+
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+
+			if url != tt.expectedURL {
+				t.Errorf("Expected url %q, got %q", tt.expectedURL, url)
+			}
+		})
+	}
+}
+
+func TestRoute_Name_Duplicate(t *testing.T) {
+	e := New()
+	e.GET("/a", func(c *Context) {}).Name("dup")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate route name")
+		}
+	}()
+
+	e.GET("/b", func(c *Context) { c.Status(http.StatusOK) }).Name("dup")
+}
+
+func namedHandler(c *Context) {}
+
+func TestEngine_Routes(t *testing.T) {
+	e := Default()
+	e.GET("/", namedHandler).Name("index")
+	api := e.Group("/api")
+	api.Use(func(c *Context) { c.Next() })
+	api.POST("/users", func(c *Context) {})
+	e.GET("/", namedHandler)
+
+	routes := e.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("Expected 2 routes, got %d: %v", len(routes), routes)
+	}
+
+	expected := []RouteInfo{
+		{Method: "GET", Pattern: "/", Name: "index", Handler: "github.com/loveRyujin/gee.namedHandler", Middlewares: 1},
+		{Method: "POST", Pattern: "/api/users", Handler: "github.com/loveRyujin/gee.TestEngine_Routes.func2", Middlewares: 2},
+	}
+	for i, want := range expected {
+		if routes[i] != want {
+			t.Errorf("Expected routes[%d] = %+v, got %+v", i, want, routes[i])
+		}
+	}
+}
+
+func TestEngine_URLFor_Constraint(t *testing.T) {
+	e := New()
+	e.GET("/user/{id:int}", func(c *Context) {}).Name("user")
+
+	if url, err := e.URLFor("user", "id", "42"); err != nil || url != "/user/42" {
+		t.Errorf("Expected /user/42, got %q (%v)", url, err)
+	}
+
+	if _, err := e.URLFor("user", "id", "abc"); err == nil {
+		t.Error("Expected error for value violating constraint")
+	}
+}
@@ -189,7 +189,7 @@ func TestRouteGroup_PrefixConcatenation(t *testing.T) {
 	// 测试路由是否正确注册
 	expectedPattern := "/api/v1/users/:id"
 	key := "GET_" + expectedPattern
-	if _, ok := e.router.handlers[key]; !ok {
+	if _, ok := e.router.load().handlers[key]; !ok {
 		t.Errorf("Route %s not found in handlers", key)
 	}
 
@@ -519,3 +519,108 @@ func TestRouteGroup_Use_MiddlewareWithoutNext(t *testing.T) {
 		t.Errorf("Expected body 'ForbiddenTest', got %q", rr.Body.String())
 	}
 }
+
+func TestRouteGroup_RouteMiddleware(t *testing.T) {
+	e := New()
+
+	executionOrder := make([]string, 0)
+	record := func(name string) Handler {
+		return func(c *Context) {
+			executionOrder = append(executionOrder, name)
+			c.Next()
+		}
+	}
+
+	api := e.Group("/api")
+	api.Use(record("group"))
+	api.GET("/test", record("route-1"), record("route-2"), func(c *Context) {
+		executionOrder = append(executionOrder, "handler")
+		c.String(http.StatusOK, "OK")
+	})
+	api.GET("/plain", func(c *Context) {
+		executionOrder = append(executionOrder, "plain")
+	})
+
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, httptest.NewRequest("GET", "/api/test", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/plain", nil))
+
+	expectedOrder := []string{"group", "route-1", "route-2", "handler", "group", "plain"}
+	if len(executionOrder) != len(expectedOrder) {
+		t.Fatalf("Expected order %v, got %v", expectedOrder, executionOrder)
+	}
+	for i, expected := range expectedOrder {
+		if executionOrder[i] != expected {
+			t.Errorf("Expected order[%d] = %q, got %q. Full order: %v", i, expected, executionOrder[i], executionOrder)
+		}
+	}
+
+	if routes := e.Routes(); routes[0].Middlewares != 3 {
+		t.Errorf("Expected 3 middlewares in front of the route, got %d", routes[0].Middlewares)
+	}
+}
+
+func TestRouteGroup_Methods(t *testing.T) {
+	e := New()
+	g := e.Group("/api")
+	register := map[string]func(string, ...Handler) *Route{
+		"GET":     g.GET,
+		"POST":    g.POST,
+		"PUT":     g.PUT,
+		"PATCH":   g.PATCH,
+		"DELETE":  g.DELETE,
+		"HEAD":    g.HEAD,
+		"OPTIONS": g.OPTIONS,
+	}
+	for _, fn := range register {
+		fn("/res", func(c *Context) {
+			c.String(http.StatusOK, "%s", c.Method())
+		})
+	}
+
+	for method := range register {
+		t.Run(method, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, httptest.NewRequest(method, "/api/res", nil))
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+			}
+			if rr.Body.String() != method {
+				t.Errorf("Expected body %q, got %q", method, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouteGroup_InvalidChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(e *Engine)
+	}{
+		{
+			name: "no handlers",
+			register: func(e *Engine) {
+				e.GET("/empty")
+			},
+		},
+		{
+			name: "chain too long",
+			register: func(e *Engine) {
+				e.Use(make([]Handler, maxChainLength)...)
+				e.GET("/long", func(c *Context) {})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected panic")
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
@@ -2,21 +2,48 @@ package gee
 
 import (
 	"fmt"
-	"log"
+	"maps"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-type router struct {
+// routeTable is one version of a router's routes. It is never modified
+// once published, so lookups read it without a lock.
+type routeTable struct {
 	roots    map[string]*node
-	handlers map[string]Handler
+	handlers map[string]HandlerChain
+}
+
+type router struct {
+	mu    sync.Mutex // serializes writers
+	table atomic.Pointer[routeTable]
 }
 
 func newRouter() *router {
-	return &router{
+	r := &router{}
+	r.table.Store(&routeTable{
 		roots:    make(map[string]*node),
-		handlers: make(map[string]Handler),
-	}
+		handlers: make(map[string]HandlerChain),
+	})
+	return r
+}
+
+func (r *router) load() *routeTable {
+	return r.table.Load()
+}
+
+// update copies the current table, lets fn change the copy and swaps it in.
+func (r *router) update(fn func(t *routeTable)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.load()
+	t := &routeTable{roots: maps.Clone(old.roots), handlers: maps.Clone(old.handlers)}
+	fn(t)
+	r.table.Store(t)
 }
 
 func parsePattern(pattern string) []string {
@@ -35,33 +62,64 @@ func parsePattern(pattern string) []string {
 	return parts
 }
 
-func (r *router) addRoute(method, pattern string, handler Handler) {
+func (r *router) addRoute(method, pattern string, handlers ...Handler) {
 	parts := parsePattern(pattern)
 
 	key := fmt.Sprintf("%s_%s", method, pattern)
-	_, ok := r.roots[method]
-	if !ok {
-		r.roots[method] = &node{}
+	r.update(func(t *routeTable) {
+		root, ok := t.roots[method]
+		if !ok {
+			root = &node{}
+		}
+		if _, ok := t.handlers[key]; ok {
+			debugWarning("Route %4s - %s is registered again, the previous handler is replaced", method, pattern)
+		}
+		t.roots[method] = root.insert(pattern, parts, 0)
+		t.handlers[key] = handlers
+	})
+	debugPrintf("Route %4s - %s", method, pattern)
+}
+
+// removeRoute reports whether the route was registered.
+func (r *router) removeRoute(method, pattern string) bool {
+	key := fmt.Sprintf("%s_%s", method, pattern)
+	removed := false
+	r.update(func(t *routeTable) {
+		if _, removed = t.handlers[key]; !removed {
+			return
+		}
+		delete(t.handlers, key)
+		if root := t.roots[method].remove(pattern, parsePattern(pattern), 0); root != nil {
+			t.roots[method] = root
+		} else {
+			delete(t.roots, method)
+		}
+	})
+	if removed {
+		debugPrintf("Route %4s - %s removed", method, pattern)
 	}
-	r.roots[method].insert(pattern, parts, 0)
-	r.handlers[key] = handler
-	log.Printf("Route %4s - %s", method, pattern)
+
+	return removed
 }
 
 func (r *router) getRoute(method string, path string) (*node, map[string]string) {
+	return r.load().getRoute(method, path)
+}
+
+func (t *routeTable) getRoute(method string, path string) (*node, map[string]string) {
 	searchParts := parsePattern(path)
 	params := make(map[string]string)
-	root, ok := r.roots[method]
+	root, ok := t.roots[method]
 	if !ok {
 		return nil, nil
 	}
 
-	n := root.search(searchParts, 0)
+	n := root.search(searchParts, 0, hasTrailingSlash(path))
 	if n != nil {
 		parts := parsePattern(n.pattern)
 		for index, part := range parts {
-			if part[0] == ':' {
-				params[part[1:]] = searchParts[index]
+			if isParamPart(part) {
+				params[paramName(part)] = searchParts[index]
 			}
 			if part[0] == '*' && len(part) > 1 {
 				params[part[1:]] = strings.Join(searchParts[index:], "/")
@@ -75,13 +133,21 @@ func (r *router) getRoute(method string, path string) (*node, map[string]string)
 }
 
 func (r *router) handle(c *Context) {
-	n, params := r.getRoute(c.method, c.path)
+	t := r.load()
+	n, params := t.getRoute(c.method, c.path)
 	if n != nil {
-		c.params = params
+		unescape := c.engine != nil && c.engine.UseRawPath && c.engine.UnescapePathValues
+		for k, v := range params {
+			if unescape {
+				if u, err := url.PathUnescape(v); err == nil {
+					v = u
+				}
+			}
+			c.params[k] = v
+		}
+		c.fullPath = n.pattern
 		key := fmt.Sprintf("%s_%s", c.method, n.pattern)
-		c.handlers = append(c.handlers, func(c *Context) {
-			r.handlers[key](c)
-		})
+		c.handlers = append(c.handlers, t.handlers[key]...)
 	} else {
 		c.handlers = append(c.handlers, func(c *Context) {
 			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.path)
@@ -13,20 +13,20 @@ func TestNewRouter(t *testing.T) {
 		t.Fatal("newRouter() returned nil")
 	}
 
-	if r.handlers == nil {
+	if r.load().handlers == nil {
 		t.Fatal("newRouter() handlers is nil")
 	}
 
-	if r.roots == nil {
+	if r.load().roots == nil {
 		t.Fatal("newRouter() roots is nil")
 	}
 
-	if len(r.handlers) != 0 {
-		t.Errorf("Expected empty handlers map, got %d handlers", len(r.handlers))
+	if len(r.load().handlers) != 0 {
+		t.Errorf("Expected empty handlers map, got %d handlers", len(r.load().handlers))
 	}
 
-	if len(r.roots) != 0 {
-		t.Errorf("Expected empty roots map, got %d roots", len(r.roots))
+	if len(r.load().roots) != 0 {
+		t.Errorf("Expected empty roots map, got %d roots", len(r.load().roots))
 	}
 }
 
@@ -74,12 +74,12 @@ func TestRouter_addRoute(t *testing.T) {
 
 			r.addRoute(tt.method, tt.pattern, handler)
 
-			if _, ok := r.handlers[tt.expectedKey]; !ok {
+			if _, ok := r.load().handlers[tt.expectedKey]; !ok {
 				t.Errorf("Route %s not found in handlers", tt.expectedKey)
 			}
 
-			if len(r.handlers) != 1 {
-				t.Errorf("Expected 1 handler, got %d", len(r.handlers))
+			if len(r.load().handlers) != 1 {
+				t.Errorf("Expected 1 handler, got %d", len(r.load().handlers))
 			}
 		})
 	}
@@ -508,3 +508,70 @@ func TestRouter_handle_DynamicRoutes(t *testing.T) {
 	}
 }
 
+
+func TestRouter_removeRoute(t *testing.T) {
+	tests := []struct {
+		name            string
+		remove          string
+		expectedRemoved bool
+		expectedPattern map[string]string
+	}{
+		{
+			name:            "remove static route",
+			remove:          "/users/list",
+			expectedRemoved: true,
+			expectedPattern: map[string]string{"/users/list": "/users/:id", "/users/7": "/users/:id", "/users/7/posts": "/users/:id/posts"},
+		},
+		{
+			name:            "remove param route keeps its children",
+			remove:          "/users/:id",
+			expectedRemoved: true,
+			expectedPattern: map[string]string{"/users/list": "/users/list", "/users/7": "", "/users/7/posts": "/users/:id/posts"},
+		},
+		{
+			name:            "remove unknown route",
+			remove:          "/users",
+			expectedRemoved: false,
+			expectedPattern: map[string]string{"/users/list": "/users/list", "/users/7": "/users/:id", "/users/7/posts": "/users/:id/posts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter()
+			handler := func(c *Context) {}
+			r.addRoute("GET", "/users/list", handler)
+			r.addRoute("GET", "/users/:id", handler)
+			r.addRoute("GET", "/users/:id/posts", handler)
+			before := r.load()
+
+			if removed := r.removeRoute("GET", tt.remove); removed != tt.expectedRemoved {
+				t.Errorf("Expected removed %v, got %v", tt.expectedRemoved, removed)
+			}
+			for path, expected := range tt.expectedPattern {
+				var pattern string
+				if n, _ := r.getRoute("GET", path); n != nil {
+					pattern = n.pattern
+				}
+				if pattern != expected {
+					t.Errorf("Expected %s to match %q, got %q", path, expected, pattern)
+				}
+			}
+
+			// a table already handed to a request is never changed
+			if n, _ := before.getRoute("GET", tt.remove); tt.expectedRemoved && n == nil {
+				t.Errorf("Expected the old table to still route %s", tt.remove)
+			}
+		})
+	}
+}
+
+func TestRouter_removeLastRoute(t *testing.T) {
+	r := newRouter()
+	r.addRoute("DELETE", "/items/:id", func(c *Context) {})
+	r.removeRoute("DELETE", "/items/:id")
+
+	if len(r.load().roots) != 0 || len(r.load().handlers) != 0 {
+		t.Errorf("Expected empty table, got %d roots and %d handlers", len(r.load().roots), len(r.load().handlers))
+	}
+}
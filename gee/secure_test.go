@@ -0,0 +1,184 @@
+package gee
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecure_Headers(t *testing.T) {
+	tests := []struct {
+		name            string
+		cfg             SecureConfig
+		tls             bool
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "defaults over plain HTTP",
+			cfg:  SecureConfig{},
+			expectedHeaders: map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "strict-origin-when-cross-origin",
+				"Permissions-Policy":        "camera=(), microphone=(), geolocation=()",
+				"Content-Security-Policy":   "default-src 'self'; base-uri 'self'; object-src 'none'; frame-ancestors 'none'",
+				"Strict-Transport-Security": "",
+			},
+		},
+		{
+			name: "HSTS over HTTPS",
+			cfg:  SecureConfig{HSTSMaxAge: time.Hour, HSTSIncludeSubdomains: true, HSTSPreload: true},
+			tls:  true,
+			expectedHeaders: map[string]string{
+				"Strict-Transport-Security": "max-age=3600; includeSubDomains; preload",
+			},
+		},
+		{
+			name: "HSTS disabled",
+			cfg:  SecureConfig{HSTSMaxAge: -1},
+			tls:  true,
+			expectedHeaders: map[string]string{
+				"Strict-Transport-Security": "",
+			},
+		},
+		{
+			name: "overrides and omissions",
+			cfg: SecureConfig{
+				FrameOptions:          "SAMEORIGIN",
+				PermissionsPolicy:     SecureOmit,
+				ContentSecurityPolicy: "default-src 'none'",
+				CSPReportOnly:         true,
+			},
+			expectedHeaders: map[string]string{
+				"X-Frame-Options":                     "SAMEORIGIN",
+				"Permissions-Policy":                  "",
+				"Content-Security-Policy":             "",
+				"Content-Security-Policy-Report-Only": "default-src 'none'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.Use(Secure(tt.cfg))
+			e.GET("/", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			for k, v := range tt.expectedHeaders {
+				if got := rr.Header().Get(k); got != v {
+					t.Errorf("Expected %s %q, got %q", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSecure_CSPNonce(t *testing.T) {
+	e := New()
+	e.Use(Secure(SecureConfig{ContentSecurityPolicy: "script-src 'nonce-{nonce}'; style-src 'nonce-{nonce}'"}))
+	e.GET("/", func(c *Context) { c.String(http.StatusOK, "%s", c.CSPNonce()) })
+
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		e.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+		nonce := rr.Body.String()
+		if nonce == "" || seen[nonce] {
+			t.Fatalf("Expected a fresh nonce per request, got %q", nonce)
+		}
+		seen[nonce] = true
+
+		expected := "script-src 'nonce-" + nonce + "'; style-src 'nonce-" + nonce + "'"
+		if got := rr.Header().Get("Content-Security-Policy"); got != expected {
+			t.Errorf("Expected policy %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestSecure_SSLRedirect(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		remoteAddr       string
+		forwardedProto   string
+		sslHost          string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:             "plain GET",
+			method:           "GET",
+			remoteAddr:       "192.0.2.1:1234",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://example.com/path?q=1",
+		},
+		{
+			name:             "plain POST keeps the method",
+			method:           "POST",
+			remoteAddr:       "192.0.2.1:1234",
+			expectedStatus:   http.StatusPermanentRedirect,
+			expectedLocation: "https://example.com/path?q=1",
+		},
+		{
+			name:           "HTTPS at a trusted proxy",
+			method:         "GET",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedProto: "https",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "forwarded proto from an untrusted peer",
+			method:           "GET",
+			remoteAddr:       "192.0.2.1:1234",
+			forwardedProto:   "https",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://example.com/path?q=1",
+		},
+		{
+			name:             "custom host",
+			method:           "GET",
+			remoteAddr:       "192.0.2.1:1234",
+			sslHost:          "secure.example.com",
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "https://secure.example.com/path?q=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.SetTrustedProxies([]string{"10.0.0.0/8"})
+			e.Use(Secure(SecureConfig{SSLRedirect: true, SSLHost: tt.sslHost}))
+			e.Handle(tt.method, "/path", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+			req := httptest.NewRequest(tt.method, "/path?q=1", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedProto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.forwardedProto)
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Location"); got != tt.expectedLocation {
+				t.Errorf("Expected location %q, got %q", tt.expectedLocation, got)
+			}
+			if tt.expectedStatus == http.StatusOK && !strings.HasPrefix(rr.Header().Get("Strict-Transport-Security"), "max-age=") {
+				t.Errorf("Expected HSTS behind a trusted HTTPS proxy")
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package gee
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func serveLoopback(t *testing.T, e *Engine, serve func(srv *http.Server, ln net.Listener) error) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := e.Server("")
+	go serve(srv, ln)
+	t.Cleanup(func() { srv.Close() })
+
+	return ln.Addr().String()
+}
+
+func protoEngine(h2c bool) *Engine {
+	e := New()
+	e.H2C = h2c
+	e.GET("/proto", func(c *Context) {
+		c.String(http.StatusOK, "%s", c.Request().Proto)
+	})
+	return e
+}
+
+func TestEngine_H2CPriorKnowledge(t *testing.T) {
+	tests := []struct {
+		name          string
+		h2c           bool
+		expectedProto string
+	}{
+		{name: "enabled", h2c: true, expectedProto: "HTTP/2.0"},
+		{name: "disabled", h2c: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := serveLoopback(t, protoEngine(tt.h2c), (*http.Server).Serve)
+
+			protocols := new(http.Protocols)
+			protocols.SetUnencryptedHTTP2(true)
+			client := &http.Client{Transport: &http.Transport{Protocols: protocols}, Timeout: 2 * time.Second}
+
+			resp, err := client.Get("http://" + addr + "/proto")
+			if tt.expectedProto == "" {
+				if err == nil {
+					resp.Body.Close()
+					t.Errorf("Expected prior knowledge HTTP/2 to fail without H2C")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.ProtoMajor != 2 {
+				t.Errorf("Expected HTTP/2 response, got %s", resp.Proto)
+			}
+			body := make([]byte, 16)
+			n, _ := resp.Body.Read(body)
+			if string(body[:n]) != tt.expectedProto {
+				t.Errorf("Expected body %q, got %q", tt.expectedProto, body[:n])
+			}
+		})
+	}
+}
+
+// h2cUpgradeGet sends GET path with Upgrade: h2c over a raw connection and
+// returns the body of the response on stream 1. Frames are read by hand so
+// the test needs no HTTP/2 client.
+func h2cUpgradeGet(addr, path string) (string, error) {
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: "+addr+"\r\n"+
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: \r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return "", fmt.Errorf("expected 101, got %s", resp.Status)
+	}
+
+	// preface and an empty SETTINGS frame
+	io.WriteString(conn, h2cPreface)
+	conn.Write(h2cFrame(0x4, 0, 0, nil))
+
+	var body []byte
+	header := make([]byte, 9)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			return "", err
+		}
+		payload := make([]byte, int(header[0])<<16|int(header[1])<<8|int(header[2]))
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return "", err
+		}
+		typ, flags, stream := header[3], header[4], binary.BigEndian.Uint32(header[5:])&0x7fffffff
+
+		switch {
+		case typ == 0x4 && flags&0x1 == 0:
+			conn.Write(h2cFrame(0x4, 0x1, 0, nil))
+		case typ == 0x0 && stream == 1:
+			body = append(body, payload...)
+			if flags&0x1 != 0 {
+				return string(body), nil
+			}
+		case typ == 0x3 && stream == 1:
+			return "", errors.New("stream 1 was reset")
+		}
+	}
+}
+
+func TestEngine_H2CUpgrade(t *testing.T) {
+	addr := serveLoopback(t, protoEngine(true), (*http.Server).Serve)
+
+	body, err := h2cUpgradeGet(addr, "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "HTTP/2.0" {
+		t.Errorf("Expected body %q, got %q", "HTTP/2.0", body)
+	}
+}
+
+func TestEngine_ShutdownWaitsForH2C(t *testing.T) {
+	priorKnowledgeGet := func(addr, path string) (string, error) {
+		protocols := new(http.Protocols)
+		protocols.SetUnencryptedHTTP2(true)
+		client := &http.Client{Transport: &http.Transport{Protocols: protocols}, Timeout: 2 * time.Second}
+		resp, err := client.Get("http://" + addr + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	tests := []struct {
+		name string
+		get  func(addr, path string) (string, error)
+	}{
+		{name: "prior knowledge", get: priorKnowledgeGet},
+		{name: "upgrade", get: h2cUpgradeGet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			release := make(chan struct{})
+			e := New()
+			e.H2C = true
+			e.GET("/slow", func(c *Context) {
+				close(started)
+				<-release
+				c.String(http.StatusOK, "%s", c.Request().Proto)
+			})
+			addr := serveLoopback(t, e, (*http.Server).Serve)
+
+			type result struct {
+				body string
+				err  error
+			}
+			done := make(chan result, 1)
+			go func() {
+				body, err := tt.get(addr, "/slow")
+				done <- result{body, err}
+			}()
+			<-started
+
+			shutdown := make(chan error, 1)
+			go func() { shutdown <- e.Shutdown(context.Background()) }()
+			select {
+			case err := <-shutdown:
+				t.Fatalf("Expected Shutdown to wait for the in-flight request, returned %v", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			close(release)
+			if res := <-done; res.err != nil || res.body != "HTTP/2.0" {
+				t.Errorf("Expected the request to finish over HTTP/2, got %q, %v", res.body, res.err)
+			}
+			if err := <-shutdown; err != nil {
+				t.Errorf("Expected a clean shutdown, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEngine_TLSNegotiatesHTTP2(t *testing.T) {
+	certFile, keyFile, pool := writeTestCert(t)
+	addr := serveLoopback(t, protoEngine(false), func(srv *http.Server, ln net.Listener) error {
+		return srv.ServeTLS(ln, certFile, keyFile)
+	})
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}, ForceAttemptHTTP2: true},
+		Timeout:   2 * time.Second,
+	}
+	resp, err := client.Get("https://" + addr + "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2 over TLS, got %s", resp.Proto)
+	}
+}
+
+func writeTestCert(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+
+	cert, _ := x509.ParseCertificate(der)
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return certFile, keyFile, pool
+}
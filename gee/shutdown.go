@@ -0,0 +1,54 @@
+package gee
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// OnShutdown registers fn to run once graceful shutdown begins, whether
+// through Engine.Shutdown or Shutdown on a server from Engine.Server.
+// Hooks run before in-flight requests have drained, so they suit things
+// like failing readiness probes.
+func (e *Engine) OnShutdown(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.shutdownHooks = append(e.shutdownHooks, fn)
+}
+
+func (e *Engine) ShuttingDown() bool {
+	return e.shuttingDown.Load()
+}
+
+// Shutdown gracefully stops every server Run, RunTLS or Server started,
+// waiting for in-flight requests until ctx is done.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.beginShutdown()
+
+	e.mu.Lock()
+	servers := slices.Clone(e.servers)
+	e.mu.Unlock()
+
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Engine) beginShutdown() {
+	if !e.shuttingDown.CompareAndSwap(false, true) {
+		return
+	}
+
+	e.mu.Lock()
+	hooks := slices.Clone(e.shutdownHooks)
+	e.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
@@ -0,0 +1,66 @@
+package gee
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEngine_Shutdown(t *testing.T) {
+	var hooks atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	e := New()
+	e.OnShutdown(func() { hooks.Add(1) })
+	e.GET("/slow", func(c *Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go e.Server("").Serve(ln)
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- e.Shutdown(context.Background()) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !e.ShuttingDown() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the engine to report shutting down")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if got := <-body; got != "done" {
+		t.Errorf("Expected the in-flight request to finish, got %q", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+	if n := hooks.Load(); n != 1 {
+		t.Errorf("Expected the hook to run once, got %d", n)
+	}
+}
@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/loveRyujin/gee"
+)
+
+const spanKey = "gee.tracing.span"
+
+// Middleware continues the trace from the incoming traceparent, or starts
+// a new one, and records a span named after the matched route pattern.
+func Middleware(t Tracer) gee.Handler {
+	return func(c *gee.Context) {
+		parent, _ := Extract(c.Request().Header)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		span := t.StartSpan(c.Method()+" "+route, parent)
+		span.SetAttribute("http.method", c.Method())
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.target", c.Request().URL.RequestURI())
+		c.Set(spanKey, span)
+
+		defer func() {
+			if r := recover(); r != nil {
+				span.Error = true
+				span.End()
+				panic(r)
+			}
+
+			status := c.StatusCode()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttribute("http.status_code", strconv.Itoa(status))
+			span.Error = status >= http.StatusInternalServerError
+			span.End()
+		}()
+
+		c.Next()
+	}
+}
+
+// SpanFromContext returns the request span started by Middleware.
+func SpanFromContext(c *gee.Context) (*Span, bool) {
+	v, ok := c.Get(spanKey)
+	if !ok {
+		return nil, false
+	}
+	span, ok := v.(*Span)
+	return span, ok
+}
+
+// InjectRequest propagates the active span of c to an outgoing request.
+func InjectRequest(c *gee.Context, req *http.Request) {
+	if span, ok := SpanFromContext(c); ok {
+		Inject(span.Context(), req.Header)
+	}
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loveRyujin/gee"
+	"github.com/loveRyujin/gee/geetest"
+)
+
+func TestMain(m *testing.M) {
+	gee.SetMode(gee.TestMode)
+	m.Run()
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	e := gee.Default()
+	e.Use(Middleware(NewTracer(exporter)))
+
+	var outgoing http.Header
+	e.GET("/users/:id", func(c *gee.Context) {
+		req := httptest.NewRequest("GET", "http://backend/", nil)
+		InjectRequest(c, req)
+		outgoing = req.Header
+		c.String(http.StatusOK, "ok")
+	})
+	e.GET("/panic", func(c *gee.Context) {
+		panic("boom")
+	})
+
+	client := geetest.New(e)
+
+	client.GET("/users/1").
+		Header(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+		Header(TraceStateHeader, "rojo=1").
+		Do(t).
+		Status(http.StatusOK)
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /users/:id" {
+		t.Errorf("Expected span name %q, got %q", "GET /users/:id", span.Name)
+	}
+	if span.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.ParentSpanID.String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected span to continue incoming trace, got %s parent %s", span.TraceID, span.ParentSpanID)
+	}
+	if span.Attributes["http.status_code"] != "200" {
+		t.Errorf("Expected status attribute 200, got %q", span.Attributes["http.status_code"])
+	}
+	if want := span.Context().TraceParent(); outgoing.Get(TraceParentHeader) != want {
+		t.Errorf("Expected outgoing traceparent %q, got %q", want, outgoing.Get(TraceParentHeader))
+	}
+	if outgoing.Get(TraceStateHeader) != "rojo=1" {
+		t.Errorf("Expected outgoing tracestate %q, got %q", "rojo=1", outgoing.Get(TraceStateHeader))
+	}
+
+	exporter.Reset()
+	client.GET("/panic").Do(t).Status(http.StatusInternalServerError)
+	spans = exporter.Spans()
+	if len(spans) != 1 || !spans[0].Error || spans[0].ParentSpanID.IsValid() {
+		t.Errorf("Expected one root error span, got %+v", spans)
+	}
+}
+
+func TestMiddleware_NotSampled(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	e := gee.New()
+	e.Use(Middleware(NewTracer(exporter)))
+	e.GET("/", func(c *gee.Context) {})
+
+	geetest.New(e).GET("/").
+		Header(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00").
+		Do(t)
+
+	if n := len(exporter.Spans()); n != 0 {
+		t.Errorf("Expected unsampled span not to be exported, got %d", n)
+	}
+}
+
+func TestJSONLinesExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(NewJSONLinesExporter(&buf))
+
+	root := tracer.StartSpan("root", SpanContext{})
+	child := tracer.StartSpan("child", root.Context())
+	child.End()
+	root.End()
+	root.End()
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var got struct {
+		Name         string `json:"name"`
+		TraceID      string `json:"trace_id"`
+		ParentSpanID string `json:"parent_span_id"`
+	}
+	if err := json.Unmarshal(lines[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "child" || got.TraceID != root.TraceID.String() || got.ParentSpanID != root.SpanID.String() {
+		t.Errorf("Unexpected child span line %s", lines[0])
+	}
+}
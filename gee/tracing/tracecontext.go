@@ -0,0 +1,166 @@
+// Package tracing propagates W3C Trace Context across gee services and
+// records a span per request.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+
+	maxTraceStateMembers = 32
+)
+
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+func (t TraceID) IsValid() bool  { return t != TraceID{} }
+func (s SpanID) IsValid() bool   { return s != SpanID{} }
+
+func (t TraceID) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+func (s SpanID) MarshalText() ([]byte, error)  { return []byte(s.String()), nil }
+
+const FlagSampled byte = 0x01
+
+// SpanContext is the part of a span that crosses process boundaries.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// TraceParent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) TraceParent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// Extract reads traceparent and tracestate from header. ok is false when
+// there is no valid traceparent, in which case tracestate is ignored too.
+func Extract(header http.Header) (sc SpanContext, ok bool) {
+	sc, ok = ParseTraceParent(header.Get(TraceParentHeader))
+	if !ok {
+		return SpanContext{}, false
+	}
+
+	sc.TraceState = parseTraceState(header.Values(TraceStateHeader))
+	return sc, true
+}
+
+// Inject writes sc into header so an outgoing request continues the trace.
+func Inject(sc SpanContext, header http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+
+	header.Set(TraceParentHeader, sc.TraceParent())
+	if sc.TraceState != "" {
+		header.Set(TraceStateHeader, sc.TraceState)
+	} else {
+		header.Del(TraceStateHeader)
+	}
+}
+
+func ParseTraceParent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, false
+	}
+
+	version, ok := decodeHex(value[0:2], 1)
+	if !ok || version[0] == 0xff {
+		return sc, false
+	}
+	// version 00 is exactly 55 chars, later versions may append fields
+	if version[0] == 0 && len(value) != 55 {
+		return sc, false
+	}
+	if version[0] != 0 && len(value) > 55 && value[55] != '-' {
+		return sc, false
+	}
+
+	traceID, ok := decodeHex(value[3:35], 16)
+	if !ok {
+		return sc, false
+	}
+	spanID, ok := decodeHex(value[36:52], 8)
+	if !ok {
+		return sc, false
+	}
+	flags, ok := decodeHex(value[53:55], 1)
+	if !ok {
+		return sc, false
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	return sc, sc.IsValid()
+}
+
+// only lowercase hex is valid in traceparent
+func decodeHex(s string, n int) ([]byte, bool) {
+	if len(s) != n*2 || strings.ToLower(s) != s {
+		return nil, false
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+// parseTraceState joins the tracestate headers and drops the whole value if
+// it is malformed or has too many members, as the spec allows.
+func parseTraceState(values []string) string {
+	members := make([]string, 0)
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			member = strings.TrimSpace(member)
+			if member == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(member, "=")
+			if !ok || k == "" || v == "" || strings.ContainsAny(k, " \t") {
+				return ""
+			}
+			members = append(members, member)
+		}
+	}
+
+	if len(members) > maxTraceStateMembers {
+		return ""
+	}
+	return strings.Join(members, ",")
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expectedOK    bool
+		expectedTrace string
+		expectedSpan  string
+		expectSampled bool
+	}{
+		{
+			name:          "valid sampled",
+			value:         "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expectedOK:    true,
+			expectedTrace: "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedSpan:  "00f067aa0ba902b7",
+			expectSampled: true,
+		},
+		{
+			name:          "valid not sampled",
+			value:         "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			expectedOK:    true,
+			expectedTrace: "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedSpan:  "00f067aa0ba902b7",
+		},
+		{
+			name:          "future version with extra field",
+			value:         "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what",
+			expectedOK:    true,
+			expectedTrace: "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedSpan:  "00f067aa0ba902b7",
+			expectSampled: true,
+		},
+		{name: "version ff", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "version 00 too long", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x"},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "uppercase hex", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "garbage", value: "hello"},
+		{name: "empty", value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceParent(tt.value)
+
+			if ok != tt.expectedOK {
+				t.Fatalf("Expected ok %v, got %v", tt.expectedOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if sc.TraceID.String() != tt.expectedTrace || sc.SpanID.String() != tt.expectedSpan {
+				t.Errorf("Unexpected span context %s/%s", sc.TraceID, sc.SpanID)
+			}
+			if sc.IsSampled() != tt.expectSampled {
+				t.Errorf("Expected sampled %v, got %v", tt.expectSampled, sc.IsSampled())
+			}
+		})
+	}
+}
+
+func TestExtractInject(t *testing.T) {
+	in := http.Header{}
+	in.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	in.Add(TraceStateHeader, "congo=t61rcWkgMzE")
+	in.Add(TraceStateHeader, "rojo=00f067aa0ba902b7")
+
+	sc, ok := Extract(in)
+	if !ok {
+		t.Fatal("Expected valid span context")
+	}
+	if sc.TraceState != "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7" {
+		t.Errorf("Unexpected tracestate %q", sc.TraceState)
+	}
+
+	out := http.Header{}
+	Inject(sc, out)
+	if out.Get(TraceParentHeader) != in.Get(TraceParentHeader) {
+		t.Errorf("Expected traceparent %q, got %q", in.Get(TraceParentHeader), out.Get(TraceParentHeader))
+	}
+	if out.Get(TraceStateHeader) != sc.TraceState {
+		t.Errorf("Expected tracestate %q, got %q", sc.TraceState, out.Get(TraceStateHeader))
+	}
+}
+
+func TestParseTraceState(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected string
+	}{
+		{name: "single", values: []string{"a=1"}, expected: "a=1"},
+		{name: "trims empty members", values: []string{"a=1, ,b=2"}, expected: "a=1,b=2"},
+		{name: "malformed member drops all", values: []string{"a=1,broken"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTraceState(tt.values); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
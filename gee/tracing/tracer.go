@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type Span struct {
+	Name         string            `json:"name"`
+	TraceID      TraceID           `json:"trace_id"`
+	SpanID       SpanID            `json:"span_id"`
+	ParentSpanID SpanID            `json:"parent_span_id"`
+	TraceState   string            `json:"trace_state,omitempty"`
+	Sampled      bool              `json:"sampled"`
+	StartTime    time.Time         `json:"start_time"`
+	EndTime      time.Time         `json:"end_time"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Error        bool              `json:"error,omitempty"`
+
+	exporter SpanExporter
+	mu       sync.Mutex
+	ended    bool
+}
+
+func (s *Span) Context() SpanContext {
+	sc := SpanContext{TraceID: s.TraceID, SpanID: s.SpanID, TraceState: s.TraceState}
+	if s.Sampled {
+		sc.Flags = FlagSampled
+	}
+	return sc
+}
+
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]string)
+	}
+	s.Attributes[key] = value
+}
+
+// End records the end time and hands sampled spans to the exporter. Only
+// the first call has an effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.Sampled && s.exporter != nil {
+		s.exporter.ExportSpan(s)
+	}
+}
+
+type Tracer interface {
+	// StartSpan starts a child of parent, or a new trace if parent is invalid.
+	StartSpan(name string, parent SpanContext) *Span
+}
+
+type SpanExporter interface {
+	ExportSpan(span *Span) error
+}
+
+type tracer struct {
+	exporter SpanExporter
+}
+
+// NewTracer returns a Tracer that samples new traces and follows the
+// sampling decision of incoming ones.
+func NewTracer(exporter SpanExporter) Tracer {
+	return &tracer{exporter: exporter}
+}
+
+func (t *tracer) StartSpan(name string, parent SpanContext) *Span {
+	span := &Span{
+		Name:      name,
+		SpanID:    newSpanID(),
+		Sampled:   true,
+		StartTime: time.Now(),
+		exporter:  t.exporter,
+	}
+
+	if parent.IsValid() {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+		span.TraceState = parent.TraceState
+		span.Sampled = parent.IsSampled()
+	} else {
+		span.TraceID = newTraceID()
+	}
+
+	return span
+}
+
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) ExportSpan(span *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]*Span(nil), e.spans...)
+}
+
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = nil
+}
+
+// JSONLinesExporter writes one JSON object per span.
+type JSONLinesExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONLinesExporter(w io.Writer) *JSONLinesExporter {
+	return &JSONLinesExporter{enc: json.NewEncoder(w)}
+}
+
+func (e *JSONLinesExporter) ExportSpan(span *Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	return e.enc.Encode(span)
+}
@@ -1,28 +1,127 @@
 package gee
 
-import "strings"
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
 
 type node struct {
-	pattern  string
-	part     string
-	children []*node
-	isWild   bool
+	pattern    string
+	slash      *node // the route registered with a trailing slash, e.g. /hello/ next to /hello
+	part       string
+	children   []*node
+	isWild     bool
+	constraint *regexp.Regexp
 }
 
-func (n *node) matchChild(part string) *node {
-	for _, child := range n.children {
-		if part == child.part || child.isWild {
-			return child
+// named constraints usable as {id:int}, anything else is a regexp
+var paramTypes = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[A-Za-z]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// isParamPart reports whether part is a :name or {name[:constraint]} segment.
+func isParamPart(part string) bool {
+	return part[0] == ':' || (len(part) > 2 && part[0] == '{' && part[len(part)-1] == '}')
+}
+
+// paramName returns the name captured by a :name, *name or {name:...} part.
+func paramName(part string) string {
+	if part[0] == '{' && isParamPart(part) {
+		name, _, _ := strings.Cut(part[1:len(part)-1], ":")
+		return name
+	}
+	return part[1:]
+}
+
+// parseConstraint compiles the constraint of a {name:constraint} part, nil
+// means the part accepts any segment.
+func parseConstraint(part string) *regexp.Regexp {
+	if part[0] != '{' || !isParamPart(part) {
+		return nil
+	}
+
+	_, expr, ok := strings.Cut(part[1:len(part)-1], ":")
+	if !ok || expr == "" {
+		return nil
+	}
+	if typed, ok := paramTypes[expr]; ok {
+		expr = typed
+	}
+
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("gee: invalid constraint in %q: %v", part, err))
+	}
+	return re
+}
+
+// priority orders siblings so that static parts are tried before
+// constrained params, then plain params, then wildcards.
+func (n *node) priority() int {
+	switch {
+	case !n.isWild:
+		return 0
+	case n.constraint != nil:
+		return 1
+	case n.part[0] != '*':
+		return 2
+	default:
+		return 3
+	}
+}
+
+// hasTrailingSlash reports whether p is the slash form of a path. parsePattern
+// maps /hello and /hello/ to the same node, which keeps both forms apart.
+func hasTrailingSlash(p string) bool {
+	return len(p) > 1 && strings.HasSuffix(p, "/")
+}
+
+// route picks the form registered for a path with or without a trailing
+// slash, or the other form if only that one is registered.
+func (n *node) route(slash bool) *node {
+	switch {
+	case n.slash != nil && (slash || n.pattern == ""):
+		return n.slash
+	case n.pattern != "":
+		return n
+	default:
+		return nil
+	}
+}
+
+func (n *node) matches(part string) bool {
+	if !n.isWild {
+		return part == n.part
+	}
+	return n.constraint == nil || n.constraint.MatchString(part)
+}
+
+// childIndex finds the child registered for exactly this part.
+func (n *node) childIndex(part string) int {
+	for i, child := range n.children {
+		if part == child.part {
+			return i
 		}
 	}
 
-	return nil
+	return -1
+}
+
+func (n *node) clone() *node {
+	c := *n
+	c.children = slices.Clone(n.children)
+	return &c
 }
 
 func (n *node) matchChildren(part string) []*node {
 	nodes := make([]*node, 0)
 	for _, child := range n.children {
-		if part == child.part || child.isWild {
+		if child.matches(part) {
 			nodes = append(nodes, child)
 		}
 	}
@@ -30,38 +129,81 @@ func (n *node) matchChildren(part string) []*node {
 	return nodes
 }
 
-func (n *node) insert(pattern string, parts []string, height int) {
+// insert returns a copy of n with pattern added. Only the nodes on the way
+// down are copied, everything else is shared with the tree a concurrent
+// search may still be walking.
+func (n *node) insert(pattern string, parts []string, height int) *node {
+	c := n.clone()
 	if len(parts) == height {
-		n.pattern = pattern
-		return
+		if hasTrailingSlash(pattern) {
+			c.slash = &node{pattern: pattern, part: c.part}
+		} else {
+			c.pattern = pattern
+		}
+		return c
 	}
 
 	part := parts[height]
-	child := n.matchChild(part)
-	if child == nil {
-		child = &node{
-			part:     part,
-			children: make([]*node, 0),
-			isWild:   (part[0] == ':' || part[0] == '*'),
+	i := c.childIndex(part)
+	if i < 0 {
+		child := &node{
+			part:       part,
+			children:   make([]*node, 0),
+			isWild:     isParamPart(part) || part[0] == '*',
+			constraint: parseConstraint(part),
+		}
+
+		i = len(c.children)
+		for i > 0 && c.children[i-1].priority() > child.priority() {
+			i--
 		}
-		n.children = append(n.children, child)
+		c.children = slices.Insert(c.children, i, child)
 	}
-	child.insert(pattern, parts, height+1)
+	c.children[i] = c.children[i].insert(pattern, parts, height+1)
+
+	return c
 }
 
-func (n *node) search(parts []string, height int) *node {
-	if len(parts) == height || strings.HasPrefix(n.part, "*") {
-		if n.pattern == "" {
-			return nil
+// remove returns a copy of n without pattern, nil once nothing is left
+// under it. The other slash form of pattern stays registered.
+func (n *node) remove(pattern string, parts []string, height int) *node {
+	c := n.clone()
+	if len(parts) == height {
+		if hasTrailingSlash(pattern) {
+			c.slash = nil
+		} else {
+			c.pattern = ""
 		}
-		return n
+	} else {
+		i := c.childIndex(parts[height])
+		if i < 0 {
+			return n
+		}
+		if child := c.children[i].remove(pattern, parts, height+1); child != nil {
+			c.children[i] = child
+		} else {
+			c.children = slices.Delete(c.children, i, i+1)
+		}
+	}
+
+	if c.pattern == "" && c.slash == nil && len(c.children) == 0 {
+		return nil
+	}
+	return c
+}
+
+// search finds the route for parts; slash tells whether the path had a
+// trailing slash.
+func (n *node) search(parts []string, height int, slash bool) *node {
+	if len(parts) == height || strings.HasPrefix(n.part, "*") {
+		return n.route(slash)
 	}
 
 	part := parts[height]
 	children := n.matchChildren(part)
 
 	for _, child := range children {
-		res := child.search(parts, height+1)
+		res := child.search(parts, height+1, slash)
 		if res != nil {
 			return res
 		}
@@ -69,3 +211,40 @@ func (n *node) search(parts []string, height int) *node {
 
 	return nil
 }
+
+// searchFold is search with static parts compared case-insensitively. It
+// also rebuilds the path with the registered casing into fixed.
+func (n *node) searchFold(parts []string, height int, slash bool, fixed []string) (*node, []string) {
+	if len(parts) == height || strings.HasPrefix(n.part, "*") {
+		route := n.route(slash)
+		if route == nil {
+			return nil, nil
+		}
+		if strings.HasPrefix(n.part, "*") {
+			// the wildcard keeps the rest of the request path as it is
+			fixed = append(fixed[:len(fixed):len(fixed)], parts[height-1:]...)
+		}
+		return route, fixed
+	}
+
+	part := parts[height]
+	for _, child := range n.children {
+		var next []string
+		switch {
+		case child.isWild && child.part[0] == '*':
+			next = fixed
+		case child.isWild && child.matches(part):
+			next = append(fixed[:len(fixed):len(fixed)], part)
+		case !child.isWild && strings.EqualFold(part, child.part):
+			next = append(fixed[:len(fixed):len(fixed)], child.part)
+		default:
+			continue
+		}
+
+		if res, path := child.searchFold(parts, height+1, slash, next); res != nil {
+			return res, path
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,99 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_Constraints(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/user/{id:int}", func(c *Context) {
+		c.String(http.StatusOK, "int %s", c.Param("id"))
+	})
+	r.addRoute("GET", "/user/{uid:uuid}", func(c *Context) {
+		c.String(http.StatusOK, "uuid %s", c.Param("uid"))
+	})
+	r.addRoute("GET", "/user/:name", func(c *Context) {
+		c.String(http.StatusOK, "name %s", c.Param("name"))
+	})
+	r.addRoute("GET", "/user/me", func(c *Context) {
+		c.String(http.StatusOK, "me")
+	})
+	r.addRoute("GET", `/file/{name:[a-z]+\.txt}`, func(c *Context) {
+		c.String(http.StatusOK, "file %s", c.Param("name"))
+	})
+	r.addRoute("GET", "/file/*rest", func(c *Context) {
+		c.String(http.StatusOK, "rest %s", c.Param("rest"))
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedBody string
+	}{
+		{name: "static wins", path: "/user/me", expectedBody: "me"},
+		{name: "int constraint", path: "/user/42", expectedBody: "int 42"},
+		{name: "negative int", path: "/user/-7", expectedBody: "int -7"},
+		{name: "uuid constraint", path: "/user/123e4567-e89b-12d3-a456-426614174000", expectedBody: "uuid 123e4567-e89b-12d3-a456-426614174000"},
+		{name: "falls through to plain param", path: "/user/bob", expectedBody: "name bob"},
+		{name: "regex constraint", path: "/file/notes.txt", expectedBody: "file notes.txt"},
+		{name: "regex is anchored", path: "/file/notes.txt.bak", expectedBody: "rest notes.txt.bak"},
+		{name: "falls through to wildcard", path: "/file/A.txt", expectedBody: "rest A.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			c := newContext(rr, req)
+
+			r.handle(c)
+
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_InvalidConstraint(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on invalid constraint")
+		}
+	}()
+
+	newRouter().addRoute("GET", "/user/{id:[0-9}", func(c *Context) {})
+}
+
+func TestContext_ParamInt(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    int
+		expectedErr bool
+	}{
+		{name: "valid", value: "42", expected: 42},
+		{name: "negative", value: "-1", expected: -1},
+		{name: "not a number", value: "abc", expectedErr: true},
+		{name: "missing", value: "", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			if tt.value != "" {
+				c.AddParam("id", tt.value)
+			}
+
+			v, err := c.ParamInt("id")
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if v != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package gee
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const defaultMultipartMemory = 32 << 20 // 32 MB
+
+// ErrBodyTooLarge is returned by the body accessors when the body is over a
+// MaxBodyBytes or Decompress limit. They don't answer the request, the
+// handler decides, usually with 413. The *http.MaxBytesError is wrapped.
+var ErrBodyTooLarge = errors.New("gee: request body too large")
+
+// MultipartForm parses the request as multipart/form-data. Up to
+// MaxMultipartMemory bytes of file data are kept in memory, larger uploads
+// spill to temp files that are removed once the request is done. An
+// oversized body fails with ErrBodyTooLarge.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	maxMemory := int64(defaultMultipartMemory)
+	if c.engine != nil && c.engine.MaxMultipartMemory > 0 {
+		maxMemory = c.engine.MaxMultipartMemory
+	}
+
+	if err := c.r.ParseMultipartForm(maxMemory); err != nil {
+		return nil, c.bodyError(err)
+	}
+
+	return c.r.MultipartForm, nil
+}
+
+// FormFile returns the first file uploaded as name. It parses the body
+// through MultipartForm and fails the same way.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.r.MultipartForm == nil {
+		if _, err := c.MultipartForm(); err != nil {
+			return nil, err
+		}
+	}
+
+	files := c.r.MultipartForm.File[name]
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+
+	return files[0], nil
+}
+
+func (c *Context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// StreamParts hands each part of a multipart body to fn as it is read off
+// the wire, so nothing is buffered beyond what fn itself keeps. It must not
+// be mixed with MultipartForm or FormFile on the same request. An oversized
+// body fails with ErrBodyTooLarge.
+func (c *Context) StreamParts(fn func(part *multipart.Part) error) error {
+	reader, err := c.r.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return c.bodyError(err)
+		}
+
+		err = fn(part)
+		part.Close()
+		if err != nil {
+			return c.bodyError(err)
+		}
+	}
+}
+
+// bodyError marks an exceeded MaxBodyBytes with ErrBodyTooLarge.
+func (c *Context) bodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrBodyTooLarge, err)
+}
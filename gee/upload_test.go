@@ -0,0 +1,204 @@
+package gee
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile(name, name+".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestContext_FormFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		field           string
+		expectedErr     bool
+		expectedContent string
+	}{
+		{
+			name:            "existing file",
+			files:           map[string]string{"avatar": "hello"},
+			field:           "avatar",
+			expectedContent: "hello",
+		},
+		{
+			name:        "missing file",
+			files:       map[string]string{"avatar": "hello"},
+			field:       "other",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newMultipartRequest(t, nil, tt.files)
+			c := newContext(httptest.NewRecorder(), req)
+
+			fh, err := c.FormFile(tt.field)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if tt.expectedErr {
+				return
+			}
+
+			f, _ := fh.Open()
+			defer f.Close()
+			content, _ := io.ReadAll(f)
+			if string(content) != tt.expectedContent {
+				t.Errorf("Expected content %q, got %q", tt.expectedContent, content)
+			}
+		})
+	}
+}
+
+func TestContext_MultipartForm_SpillsToDisk(t *testing.T) {
+	e := New()
+	e.MaxMultipartMemory = 16
+
+	e.POST("/upload", func(c *Context) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.String(http.StatusBadRequest, "%s", err)
+			return
+		}
+
+		f, _ := form.File["big"][0].Open()
+		defer f.Close()
+		if _, ok := f.(*os.File); !ok {
+			c.String(http.StatusOK, "memory")
+			return
+		}
+		c.String(http.StatusOK, "disk %s", form.Value["name"][0])
+	})
+
+	req := newMultipartRequest(t, map[string]string{"name": "gee"}, map[string]string{"big": strings.Repeat("x", 1024)})
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+
+	if rr.Body.String() != "disk gee" {
+		t.Errorf("Expected body %q, got %q", "disk gee", rr.Body.String())
+	}
+}
+
+func TestContext_SaveUploadedFile(t *testing.T) {
+	req := newMultipartRequest(t, nil, map[string]string{"doc": "content"})
+	c := newContext(httptest.NewRecorder(), req)
+
+	fh, err := c.FormFile("doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "nested", "doc.txt")
+	if err := c.SaveUploadedFile(fh, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != "content" {
+		t.Errorf("Expected saved content %q, got %q", "content", saved)
+	}
+}
+
+func TestEngine_MaxBodyBytes(t *testing.T) {
+	tests := []struct {
+		name           string
+		chunked        bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "content length over limit",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "request body too large\n",
+		},
+		{
+			name:           "chunked body over limit",
+			chunked:        true,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "limit 64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.MaxBodyBytes = 64
+			e.POST("/upload", func(c *Context) {
+				_, err := c.FormFile("big")
+				var maxErr *http.MaxBytesError
+				if errors.Is(err, ErrBodyTooLarge) && errors.As(err, &maxErr) {
+					c.String(http.StatusRequestEntityTooLarge, "limit %d", maxErr.Limit)
+					return
+				}
+				c.String(http.StatusOK, "OK")
+			})
+
+			req := newMultipartRequest(t, nil, map[string]string{"big": strings.Repeat("x", 1024)})
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rr := httptest.NewRecorder()
+			e.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			// the accessor leaves the response to the handler
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestContext_StreamParts(t *testing.T) {
+	req := newMultipartRequest(t, nil, map[string]string{"a": "first", "b": "second"})
+	c := newContext(httptest.NewRecorder(), req)
+
+	got := make(map[string]string)
+	err := c.StreamParts(func(part *multipart.Part) error {
+		content, err := io.ReadAll(part)
+		got[part.FormName()] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["a"] != "first" || got["b"] != "second" {
+		t.Errorf("Unexpected parts %v", got)
+	}
+}